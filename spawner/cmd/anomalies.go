@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/threefoldtech/guardians_healthchecker/spawner/internal/analysis"
+)
+
+var anomaliesCmd = &cobra.Command{
+	Use:   "anomalies",
+	Short: "flag the nodes of a run scoring far off the nodes with the same hardware on their farm",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return withExitCode(exitConfigError, fmt.Errorf("error in output format: %w", err))
+		}
+		if output != "table" && output != "json" {
+			return withExitCode(exitConfigError, fmt.Errorf("unsupported output format '%s', should be table or json", output))
+		}
+
+		_, nodes, profiles, err := runScores(cmd)
+		if err != nil {
+			return err
+		}
+
+		anomalies := analysis.FindAnomalies(nodes, profiles)
+		if len(anomalies) == 0 && output == "table" {
+			log.Info().Msg("no anomalies found")
+			return nil
+		}
+		if printErr := printAnomalies(cmd.OutOrStdout(), anomalies, output); printErr != nil {
+			log.Error().Err(printErr).Msg("failed to print anomalies")
+		}
+
+		below := make(map[uint32]bool)
+		for _, anomaly := range anomalies {
+			if anomaly.Below {
+				below[anomaly.Node] = true
+			}
+		}
+		if len(below) == 0 {
+			return nil
+		}
+
+		reported := 0
+		for _, node := range nodes {
+			if node.Reported() {
+				reported++
+			}
+		}
+		return withExitCode(failureExitCode(reported, len(below)), fmt.Errorf("%d of %d nodes scored below their farm", len(below), reported))
+	},
+}
+
+func init() {
+	anomaliesCmd.Flags().String("run", "", "ID of the run to analyze")
+	anomaliesCmd.Flags().StringP("output", "o", "table", "output format of the anomalies: table or json")
+}
+
+// printAnomalies writes the anomalies in the given format
+func printAnomalies(w io.Writer, anomalies []analysis.Anomaly, format string) error {
+	if format == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(anomalies)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "Farm\tNode\tProfile\tCategory\tScore\tMedian\tExplanation")
+	for _, anomaly := range anomalies {
+		fmt.Fprintf(tw, "%d\t%d\t%s\t%s\t%.2f\t%.2f\t%s\n",
+			anomaly.Farm, anomaly.Node, anomaly.Profile, anomaly.Category, anomaly.Score, anomaly.Median, anomaly.Explanation)
+	}
+	return tw.Flush()
+}
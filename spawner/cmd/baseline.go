@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/threefoldtech/guardians_healthchecker/spawner/internal/analysis"
+)
+
+var baselineCmd = &cobra.Command{
+	Use:   "baseline",
+	Short: "compare the benchmark results of runs against the recent results of the same nodes",
+}
+
+var baselineUpdateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "add the benchmark results of a run to the baseline",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		run, nodes, profiles, err := runScores(cmd)
+		if err != nil {
+			return err
+		}
+		path, err := baselinePath(cmd)
+		if err != nil {
+			return err
+		}
+
+		baseline, err := analysis.LoadBaseline(path)
+		if err != nil {
+			return withExitCode(exitGenericError, err)
+		}
+		if !baseline.Add(run.ID, nodes, profiles) {
+			log.Info().Str("Run", run.ID).Msg("run is already part of the baseline")
+			return nil
+		}
+		if err := baseline.Save(path); err != nil {
+			return withExitCode(exitGenericError, err)
+		}
+
+		log.Info().Str("Run", run.ID).Int("Nodes", len(baseline.Nodes)).Msg("baseline updated")
+		return nil
+	},
+}
+
+var baselineCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "flag the nodes of a run whose benchmark scores dropped below their baseline",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		threshold, err := cmd.Flags().GetFloat64("threshold")
+		if err != nil {
+			return withExitCode(exitConfigError, fmt.Errorf("error in threshold: %w", err))
+		}
+		if threshold <= 0 || threshold >= 100 {
+			return withExitCode(exitConfigError, fmt.Errorf("invalid threshold: %v, should be between 0 and 100", threshold))
+		}
+
+		run, nodes, profiles, err := runScores(cmd)
+		if err != nil {
+			return err
+		}
+		path, err := baselinePath(cmd)
+		if err != nil {
+			return err
+		}
+
+		baseline, err := analysis.LoadBaseline(path)
+		if err != nil {
+			return withExitCode(exitGenericError, err)
+		}
+		if len(baseline.Nodes) == 0 {
+			return withExitCode(exitConfigError, fmt.Errorf("baseline '%s' is empty, add runs to it with baseline update", path))
+		}
+		// the scores of a run in the baseline make up its own expected scores, it would never regress
+		if baseline.Contains(run.ID) {
+			return withExitCode(exitConfigError, fmt.Errorf("run %s is already part of baseline '%s', check a run which is not", run.ID, path))
+		}
+
+		regressions := baseline.Compare(nodes, profiles, threshold/100)
+		if len(regressions) == 0 {
+			log.Info().Msg("no regressions found")
+			return nil
+		}
+		if printErr := printRegressions(cmd.OutOrStdout(), regressions); printErr != nil {
+			log.Error().Err(printErr).Msg("failed to print regressions")
+		}
+
+		flagged := make(map[uint32]bool)
+		for _, regression := range regressions {
+			flagged[regression.Node] = true
+		}
+		reported := 0
+		for _, node := range nodes {
+			if node.Reported() {
+				reported++
+			}
+		}
+
+		return withExitCode(failureExitCode(reported, len(flagged)), fmt.Errorf("%d of %d nodes regressed", len(flagged), reported))
+	},
+}
+
+func init() {
+	baselineCmd.PersistentFlags().String("run", "", "ID of the run to use the results of")
+	baselineCheckCmd.Flags().Float64("threshold", 20, "percentage a score may drop below its baseline before it is flagged")
+
+	baselineCmd.AddCommand(baselineUpdateCmd)
+	baselineCmd.AddCommand(baselineCheckCmd)
+}
+
+// baselinePath returns the path of the baseline file in the state directory
+func baselinePath(cmd *cobra.Command) (string, error) {
+	dir, err := stateDir(cmd)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "baseline.json"), nil
+}
+
+// printRegressions writes the regressions as a table
+func printRegressions(w io.Writer, regressions []analysis.Regression) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "Farm\tNode\tCategory\tScore\tExpected\tDrop\tBaseline")
+	for _, regression := range regressions {
+		fmt.Fprintf(tw, "%d\t%d\t%s\t%.2f\t%.2f\t%.0f%%\t%s\n",
+			regression.Farm, regression.Node, regression.Category, regression.Score, regression.Expected, regression.Drop*100, regression.Source)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/threefoldtech/guardians_healthchecker/spawner/internal/daemon"
+	"github.com/threefoldtech/guardians_healthchecker/spawner/internal/metrics"
+)
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "run the configured health check campaigns on their schedule",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		metricsListen, err := cmd.Flags().GetString("metrics-listen")
+		if err != nil {
+			return withExitCode(exitConfigError, fmt.Errorf("error in metrics listen address: %w", err))
+		}
+
+		cfg, tfPluginClient, err := loadConfigAndSetup(cmd)
+		if err != nil {
+			return err
+		}
+		if len(cfg.Campaigns) == 0 {
+			return withExitCode(exitConfigError, fmt.Errorf("no campaigns are configured"))
+		}
+
+		store, err := openHistory(cmd)
+		if err != nil {
+			return err
+		}
+
+		m := metrics.New()
+		if metricsListen != "" {
+			serveMetrics(cmd.Context(), metricsListen, m)
+			watchInventory(cmd.Context(), m, cfg, tfPluginClient)
+		}
+
+		return daemon.New(cfg, tfPluginClient, store, m).Run(cmd.Context())
+	},
+}
+
+func init() {
+	daemonCmd.Flags().String("metrics-listen", "", "address to expose the Prometheus metrics on, e.g. :9100 (default: disabled)")
+}
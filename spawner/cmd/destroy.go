@@ -1,7 +1,9 @@
 package cmd
 
 import (
-	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
 
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
@@ -12,14 +14,167 @@ var destroyCmd = &cobra.Command{
 	Use:   "destroy",
 	Short: "destroy VMs on specified farms",
 	RunE: func(cmd *cobra.Command, args []string) error {
+		concurrency, err := cmd.Flags().GetInt("concurrency")
+		if err != nil {
+			return withExitCode(exitConfigError, fmt.Errorf("error in concurrency: %w", err))
+		}
+		if concurrency <= 0 {
+			return withExitCode(exitConfigError, fmt.Errorf("invalid concurrency: %d, must be a positive integer", concurrency))
+		}
+
+		opts, err := destroyOptions(cmd)
+		if err != nil {
+			return withExitCode(exitConfigError, err)
+		}
+		opts.Concurrency = concurrency
+
+		yes, err := cmd.Flags().GetBool("yes")
+		if err != nil {
+			return withExitCode(exitConfigError, fmt.Errorf("error in yes: %w", err))
+		}
+		dryRun, err := cmd.Flags().GetBool("dry-run")
+		if err != nil {
+			return withExitCode(exitConfigError, fmt.Errorf("error in dry-run: %w", err))
+		}
+
 		cfg, tfPluginClient, err := loadConfigAndSetup(cmd)
 		if err != nil {
 			return err
 		}
-		if errs := spawner.Destroy(context.Background(), cfg, tfPluginClient); errs != nil {
-			log.Error().Msg("failed to cancel deployments")
+
+		plan, err := spawner.PlanDestroy(cmd.Context(), cfg, tfPluginClient, opts)
+		if cmd.Context().Err() != nil {
+			return withExitCode(exitInterrupted, errInterrupted)
+		}
+		if printErr := printDestroyPlan(cmd.OutOrStdout(), plan); printErr != nil {
+			log.Error().Err(printErr).Msg("failed to print destroy plan")
+		}
+		if err != nil && plan.Total() == 0 {
+			return withExitCode(exitGridError, fmt.Errorf("failed to list deployments: %w", err))
 		}
 
-		return nil
+		if dryRun || plan.Total() == 0 {
+			return withExitCode(exitGridError, err)
+		}
+
+		if !yes {
+			question := fmt.Sprintf("cancel %d contracts on %d farms?", plan.Total(), len(plan.Farms))
+			confirmed, err := confirm(cmd.InOrStdin(), cmd.OutOrStdout(), question)
+			if err != nil {
+				return withExitCode(exitGenericError, err)
+			}
+			if !confirmed {
+				log.Info().Msg("destroy aborted")
+				return nil
+			}
+		}
+
+		result, err := spawner.ExecuteDestroy(cmd.Context(), tfPluginClient, plan, opts)
+
+		if printErr := printDestroyResult(cmd.OutOrStdout(), result); printErr != nil {
+			log.Error().Err(printErr).Msg("failed to print destroy result")
+		}
+
+		if cmd.Context().Err() != nil {
+			return withExitCode(exitInterrupted, errInterrupted)
+		}
+		if err == nil {
+			return nil
+		}
+
+		failed := 0
+		for _, farm := range result.Farms {
+			if farm.Error != "" {
+				failed++
+			}
+		}
+
+		return withExitCode(failureExitCode(len(result.Farms), failed), fmt.Errorf("failed to cancel deployments: %w", err))
 	},
 }
+
+func init() {
+	destroyCmd.Flags().Int("concurrency", 4, "maximum number of farms destroyed in parallel")
+	destroyCmd.Flags().UintSlice("node", nil, "only destroy the deployments on these node IDs")
+	destroyCmd.Flags().UintSlice("contract", nil, "only destroy the deployments of these contract IDs")
+	destroyCmd.Flags().String("run", "", "only destroy the deployments created by this run ID")
+	destroyCmd.Flags().Duration("older-than", 0, "only destroy the deployments created more than this duration ago, e.g. 24h")
+	destroyCmd.Flags().Bool("failed-only", false, "only destroy the deployments with workloads in error")
+	destroyCmd.Flags().BoolP("yes", "y", false, "cancel the contracts without asking for confirmation")
+	destroyCmd.Flags().Bool("dry-run", false, "only print the contracts which would be cancelled")
+}
+
+// destroyOptions reads the destroy filters from the command flags
+func destroyOptions(cmd *cobra.Command) (spawner.DestroyOptions, error) {
+	var opts spawner.DestroyOptions
+
+	nodes, err := cmd.Flags().GetUintSlice("node")
+	if err != nil {
+		return opts, fmt.Errorf("error in node IDs: %w", err)
+	}
+	for _, node := range nodes {
+		opts.Nodes = append(opts.Nodes, uint32(node))
+	}
+
+	contracts, err := cmd.Flags().GetUintSlice("contract")
+	if err != nil {
+		return opts, fmt.Errorf("error in contract IDs: %w", err)
+	}
+	for _, contract := range contracts {
+		opts.Contracts = append(opts.Contracts, uint64(contract))
+	}
+
+	opts.RunID, err = cmd.Flags().GetString("run")
+	if err != nil {
+		return opts, fmt.Errorf("error in run ID: %w", err)
+	}
+
+	opts.OlderThan, err = cmd.Flags().GetDuration("older-than")
+	if err != nil {
+		return opts, fmt.Errorf("error in older-than duration: %w", err)
+	}
+	if opts.OlderThan < 0 {
+		return opts, fmt.Errorf("invalid older-than duration: %s, must be positive", opts.OlderThan)
+	}
+
+	opts.FailedOnly, err = cmd.Flags().GetBool("failed-only")
+	if err != nil {
+		return opts, fmt.Errorf("error in failed-only: %w", err)
+	}
+
+	return opts, nil
+}
+
+// printDestroyPlan writes the contracts selected for cancellation on every farm to w.
+func printDestroyPlan(w io.Writer, plan spawner.DestroyPlan) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "Farm\tContracts\tContractIDs\tUnverified\tError")
+	for _, farm := range plan.Farms {
+		fmt.Fprintf(tw, "%d\t%d\t%s\t%s\t%s\n", farm.Farm, len(farm.Contracts), contractList(farm.Contracts), contractList(farm.Unverified), farm.Error)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "\n%d contracts to cancel\n", plan.Total())
+	return err
+}
+
+// printDestroyResult writes the cancelled and remaining contracts of every farm to w.
+func printDestroyResult(w io.Writer, result spawner.DestroyResult) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "Farm\tCancelled\tRemaining\tAttempts\tRemainingContracts\tUnverified\tError")
+	for _, farm := range result.Farms {
+		fmt.Fprintf(tw, "%d\t%d\t%d\t%d\t%s\t%s\t%s\n", farm.Farm, len(farm.Cancelled), len(farm.Remaining), farm.Attempts, contractList(farm.Remaining), contractList(farm.Unverified), farm.Error)
+	}
+
+	return tw.Flush()
+}
+
+// contractList formats contract IDs for a table cell, an empty list is shown as a dash.
+func contractList(contracts []uint64) string {
+	if len(contracts) == 0 {
+		return "-"
+	}
+	return fmt.Sprint(contracts)
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"errors"
+)
+
+// Exit codes returned by spawner commands
+const (
+	exitOK             = 0
+	exitGenericError   = 1
+	exitConfigError    = 2
+	exitGridError      = 3
+	exitPartialFailure = 4
+	exitTotalFailure   = 5
+	exitInterrupted    = 130
+)
+
+// exitError is an error carrying the exit code the process should terminate with
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+// withExitCode wraps err so that the process exits with the given code
+func withExitCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &exitError{code: code, err: err}
+}
+
+// exitCode returns the exit code the process should terminate with for the given error
+func exitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		return exitErr.code
+	}
+	return exitGenericError
+}
+
+// failureExitCode returns the exit code of an operation over several targets given how many of them failed
+func failureExitCode(total, failed int) int {
+	if failed == 0 {
+		return exitOK
+	}
+	if failed < total {
+		return exitPartialFailure
+	}
+	return exitTotalFailure
+}
+
+// errInterrupted is returned when a command is stopped by SIGINT or SIGTERM
+var errInterrupted = errors.New("interrupted")
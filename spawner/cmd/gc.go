@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/threefoldtech/guardians_healthchecker/spawner/internal/history"
+	spawner "github.com/threefoldtech/guardians_healthchecker/spawner/pkg/spawner"
+)
+
+var gcCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "cancel orphaned benchmark contracts: networks without VMs, errored deployments and deployments of unknown runs",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		knownRuns, err := cmd.Flags().GetStringSlice("keep-run")
+		if err != nil {
+			return withExitCode(exitConfigError, fmt.Errorf("error in known runs: %w", err))
+		}
+		yes, err := cmd.Flags().GetBool("yes")
+		if err != nil {
+			return withExitCode(exitConfigError, fmt.Errorf("error in yes: %w", err))
+		}
+		dryRun, err := cmd.Flags().GetBool("dry-run")
+		if err != nil {
+			return withExitCode(exitConfigError, fmt.Errorf("error in dry-run: %w", err))
+		}
+		grace, err := cmd.Flags().GetDuration("grace")
+		if err != nil {
+			return withExitCode(exitConfigError, fmt.Errorf("error in grace: %w", err))
+		}
+
+		// the runs recorded in the history are known, the ones still running are kept whole
+		store, err := openHistory(cmd)
+		if err != nil {
+			return withExitCode(exitGenericError, err)
+		}
+		runs, err := store.List()
+		if err != nil {
+			return withExitCode(exitGenericError, err)
+		}
+		opts := spawner.GCOptions{KnownRuns: knownRuns, Grace: grace}
+		for _, run := range runs {
+			opts.KnownRuns = append(opts.KnownRuns, run.ID)
+			if run.Status == history.RunningStatus {
+				opts.ActiveRuns = append(opts.ActiveRuns, run.ID)
+			}
+		}
+
+		_, tfPluginClient, err := loadConfigAndSetup(cmd)
+		if err != nil {
+			return err
+		}
+
+		orphans, err := spawner.FindOrphans(cmd.Context(), tfPluginClient, opts)
+		if cmd.Context().Err() != nil {
+			return withExitCode(exitInterrupted, errInterrupted)
+		}
+		if err != nil {
+			log.Warn().Err(err).Msg("some deployments could not be fetched")
+		}
+		if printErr := printOrphans(cmd.OutOrStdout(), orphans); printErr != nil {
+			log.Error().Err(printErr).Msg("failed to print orphaned contracts")
+		}
+
+		if dryRun || len(orphans) == 0 {
+			return nil
+		}
+
+		if !yes {
+			confirmed, err := confirm(cmd.InOrStdin(), cmd.OutOrStdout(), fmt.Sprintf("cancel %d orphaned contracts?", len(orphans)))
+			if err != nil {
+				return withExitCode(exitGenericError, err)
+			}
+			if !confirmed {
+				log.Info().Msg("gc aborted")
+				return nil
+			}
+		}
+
+		result, err := spawner.CancelOrphans(cmd.Context(), tfPluginClient, orphans)
+		if printErr := printDestroyResult(cmd.OutOrStdout(), result); printErr != nil {
+			log.Error().Err(printErr).Msg("failed to print gc result")
+		}
+
+		if cmd.Context().Err() != nil {
+			return withExitCode(exitInterrupted, errInterrupted)
+		}
+		if err == nil {
+			return nil
+		}
+
+		failed := 0
+		for _, farm := range result.Farms {
+			if farm.Error != "" {
+				failed++
+			}
+		}
+
+		return withExitCode(failureExitCode(len(result.Farms), failed), fmt.Errorf("failed to cancel orphaned contracts: %w", err))
+	},
+}
+
+func init() {
+	gcCmd.Flags().StringSlice("keep-run", nil, "run IDs whose deployments are kept, deployments of other runs are cancelled")
+	gcCmd.Flags().BoolP("yes", "y", false, "cancel the contracts without asking for confirmation")
+	gcCmd.Flags().Bool("dry-run", false, "only print the orphaned contracts")
+	gcCmd.Flags().Duration("grace", time.Hour, "keep the deployments created less than this duration ago, they may belong to a spawn in progress")
+}
+
+// printOrphans writes the orphaned contracts and why they are orphaned to w.
+func printOrphans(w io.Writer, orphans []spawner.Orphan) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "Farm\tNode\tContract\tName\tType\tRun\tReason")
+	for _, orphan := range orphans {
+		fmt.Fprintf(tw, "%d\t%d\t%d\t%s\t%s\t%s\t%s\n", orphan.Farm, orphan.Node, orphan.Contract, orphan.Name, orphan.Type, orphan.RunID, orphan.Reason)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "\n%d orphaned contracts\n", len(orphans))
+	return err
+}
@@ -1,9 +1,6 @@
 package cmd
 
 import (
-	"context"
-
-	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 	spawner "github.com/threefoldtech/guardians_healthchecker/spawner/pkg/spawner"
 )
@@ -16,11 +13,11 @@ var listCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		err = spawner.List(context.Background(), cfg, tfPluginClient)
-		if err != nil {
-			log.Fatal().Err(err).Send()
+		err = spawner.List(cmd.Context(), cfg, tfPluginClient)
+		if cmd.Context().Err() != nil {
+			return withExitCode(exitInterrupted, errInterrupted)
 		}
 
-		return nil
+		return withExitCode(exitGridError, err)
 	},
 }
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/threefoldtech/guardians_healthchecker/spawner/internal/metrics"
+	spawner "github.com/threefoldtech/guardians_healthchecker/spawner/pkg/spawner"
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-client/deployer"
+)
+
+// inventoryRefreshInterval is how often the number of live benchmark VMs is refreshed
+const inventoryRefreshInterval = 5 * time.Minute
+
+// watchInventory keeps the live benchmark VMs metric of the configured and campaign farms up to date until ctx is done
+func watchInventory(ctx context.Context, m *metrics.Metrics, cfg spawner.Config, tfPluginClient deployer.TFPluginClient) {
+	for _, campaign := range cfg.Campaigns {
+		for _, farm := range campaign.Farms {
+			if !slices.Contains(cfg.Farms, farm) {
+				cfg.Farms = append(cfg.Farms, farm)
+			}
+		}
+	}
+
+	go m.WatchInventory(ctx, inventoryRefreshInterval, cfg.Farms, func(ctx context.Context) ([]spawner.VMInfo, error) {
+		return spawner.Inventory(ctx, cfg, tfPluginClient)
+	})
+}
+
+// serveMetrics exposes the metrics on addr until ctx is done
+func serveMetrics(ctx context.Context, addr string, m *metrics.Metrics) {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", m.Handler())
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		_ = srv.Close()
+	}()
+
+	go func() {
+		log.Info().Str("Address", addr).Msg("serving metrics")
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error().Err(err).Msg("failed to serve metrics")
+		}
+	}()
+}
@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/threefoldtech/guardians_healthchecker/spawner/internal/history"
+)
+
+var quarantineCmd = &cobra.Command{
+	Use:   "quarantine",
+	Short: "manage the nodes excluded from the runs",
+}
+
+var quarantineListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list the quarantined nodes",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		all, err := cmd.Flags().GetBool("all")
+		if err != nil {
+			return withExitCode(exitConfigError, fmt.Errorf("error in all: %w", err))
+		}
+
+		store, err := openHistory(cmd)
+		if err != nil {
+			return err
+		}
+		entries, err := store.Quarantines()
+		if err != nil {
+			return withExitCode(exitGenericError, err)
+		}
+
+		now := time.Now()
+		if !all {
+			var active []history.QuarantineEntry
+			for _, entry := range entries {
+				if entry.Active(now) {
+					active = append(active, entry)
+				}
+			}
+			entries = active
+		}
+
+		return printQuarantine(cmd.OutOrStdout(), entries, now)
+	},
+}
+
+var quarantineAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "quarantine a node",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		node, err := cmd.Flags().GetUint32("node")
+		if err != nil {
+			return withExitCode(exitConfigError, fmt.Errorf("error in node: %w", err))
+		}
+		if node == 0 {
+			return withExitCode(exitConfigError, errors.New("required node ID is empty"))
+		}
+		reason, err := cmd.Flags().GetString("reason")
+		if err != nil {
+			return withExitCode(exitConfigError, fmt.Errorf("error in reason: %w", err))
+		}
+		if strings.TrimSpace(reason) == "" {
+			return withExitCode(exitConfigError, errors.New("required reason is empty"))
+		}
+		duration, err := cmd.Flags().GetDuration("duration")
+		if err != nil {
+			return withExitCode(exitConfigError, fmt.Errorf("error in duration: %w", err))
+		}
+		if duration < 0 {
+			return withExitCode(exitConfigError, fmt.Errorf("invalid duration: %s, must be positive", duration))
+		}
+
+		store, err := openHistory(cmd)
+		if err != nil {
+			return err
+		}
+
+		entry := history.QuarantineEntry{Node: node, Reason: reason, Since: time.Now()}
+		if duration != 0 {
+			entry.Until = entry.Since.Add(duration)
+		}
+		if err := store.Quarantine(entry); err != nil {
+			return withExitCode(exitGenericError, err)
+		}
+
+		log.Info().Uint32("Node", node).Msg("node quarantined")
+		return nil
+	},
+}
+
+var quarantineRemoveCmd = &cobra.Command{
+	Use:   "remove",
+	Short: "release a quarantined node",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		node, err := cmd.Flags().GetUint32("node")
+		if err != nil {
+			return withExitCode(exitConfigError, fmt.Errorf("error in node: %w", err))
+		}
+		if node == 0 {
+			return withExitCode(exitConfigError, errors.New("required node ID is empty"))
+		}
+
+		store, err := openHistory(cmd)
+		if err != nil {
+			return err
+		}
+		err = store.Release(node)
+		if errors.Is(err, history.ErrNotQuarantined) {
+			return withExitCode(exitConfigError, err)
+		}
+		if err != nil {
+			return withExitCode(exitGenericError, err)
+		}
+
+		log.Info().Uint32("Node", node).Msg("node released")
+		return nil
+	},
+}
+
+func init() {
+	quarantineListCmd.Flags().Bool("all", false, "also list the nodes whose quarantine is over")
+
+	quarantineAddCmd.Flags().Uint32("node", 0, "ID of the node to quarantine")
+	quarantineAddCmd.Flags().String("reason", "", "why the node is quarantined")
+	quarantineAddCmd.Flags().Duration("duration", 0, "how long the node is quarantined, until it is removed if 0")
+
+	quarantineRemoveCmd.Flags().Uint32("node", 0, "ID of the node to release")
+
+	quarantineCmd.AddCommand(quarantineListCmd)
+	quarantineCmd.AddCommand(quarantineAddCmd)
+	quarantineCmd.AddCommand(quarantineRemoveCmd)
+}
+
+// printQuarantine writes the quarantine entries as a table
+func printQuarantine(w io.Writer, entries []history.QuarantineEntry, now time.Time) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "Node\tFarm\tSince\tUntil\tAutomatic\tReason")
+	for _, entry := range entries {
+		farm, until := "-", "until removed"
+		if entry.Farm != 0 {
+			farm = fmt.Sprint(entry.Farm)
+		}
+		if !entry.Until.IsZero() {
+			until = entry.Until.Format(time.RFC3339)
+			if !entry.Active(now) {
+				until += " (over)"
+			}
+		}
+
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%t\t%s\n", entry.Node, farm, entry.Since.Format(time.RFC3339), until, entry.Automatic, entry.Reason)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	spawner "github.com/threefoldtech/guardians_healthchecker/spawner/pkg/spawner"
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-client/deployer"
+)
+
+var reapCmd = &cobra.Command{
+	Use:   "reap",
+	Short: "destroy benchmark deployments past their expiry",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dryRun, err := cmd.Flags().GetBool("dry-run")
+		if err != nil {
+			return withExitCode(exitConfigError, fmt.Errorf("error in dry-run: %w", err))
+		}
+		watch, err := cmd.Flags().GetDuration("watch")
+		if err != nil {
+			return withExitCode(exitConfigError, fmt.Errorf("error in watch interval: %w", err))
+		}
+		if watch < 0 {
+			return withExitCode(exitConfigError, fmt.Errorf("invalid watch interval: %s, must be positive", watch))
+		}
+
+		_, tfPluginClient, err := loadConfigAndSetup(cmd)
+		if err != nil {
+			return err
+		}
+
+		if watch == 0 {
+			err = reap(cmd, tfPluginClient, dryRun)
+			if cmd.Context().Err() != nil {
+				return withExitCode(exitInterrupted, errInterrupted)
+			}
+			return err
+		}
+
+		ticker := time.NewTicker(watch)
+		defer ticker.Stop()
+
+		for {
+			if err := reap(cmd, tfPluginClient, dryRun); err != nil && cmd.Context().Err() == nil {
+				log.Error().Err(err).Msg("reaping failed")
+			}
+
+			select {
+			case <-cmd.Context().Done():
+				log.Info().Msg("stopping reaper")
+				return nil
+			case <-ticker.C:
+			}
+		}
+	},
+}
+
+func init() {
+	reapCmd.Flags().Bool("dry-run", false, "only print the expired contracts")
+	reapCmd.Flags().Duration("watch", 0, "keep running and reap expired deployments every interval, e.g. 10m")
+}
+
+// reap cancels the expired benchmark contracts, or only prints them in dry run
+func reap(cmd *cobra.Command, tfPluginClient deployer.TFPluginClient, dryRun bool) error {
+	if dryRun {
+		expired, err := spawner.FindExpired(cmd.Context(), tfPluginClient, time.Now())
+		if printErr := printOrphans(cmd.OutOrStdout(), expired); printErr != nil {
+			log.Error().Err(printErr).Msg("failed to print expired contracts")
+		}
+		return withExitCode(exitGridError, err)
+	}
+
+	expired, result, err := spawner.Reap(cmd.Context(), tfPluginClient)
+	if len(expired) == 0 {
+		log.Info().Msg("no expired deployments")
+		return withExitCode(exitGridError, err)
+	}
+
+	if printErr := printOrphans(cmd.OutOrStdout(), expired); printErr != nil {
+		log.Error().Err(printErr).Msg("failed to print expired contracts")
+	}
+	if printErr := printDestroyResult(cmd.OutOrStdout(), result); printErr != nil {
+		log.Error().Err(printErr).Msg("failed to print reap result")
+	}
+	if err == nil {
+		return nil
+	}
+
+	failed := 0
+	for _, farm := range result.Farms {
+		if farm.Error != "" {
+			failed++
+		}
+	}
+
+	return withExitCode(failureExitCode(len(result.Farms), failed), fmt.Errorf("failed to cancel expired contracts: %w", err))
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	spawner "github.com/threefoldtech/guardians_healthchecker/spawner/pkg/spawner"
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-client/deployer"
+)
+
+var reconcileCmd = &cobra.Command{
+	Use:   "reconcile",
+	Short: "bring every farm to its desired number of benchmark VMs",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dryRun, err := cmd.Flags().GetBool("dry-run")
+		if err != nil {
+			return withExitCode(exitConfigError, fmt.Errorf("error in dry-run: %w", err))
+		}
+		watch, err := cmd.Flags().GetDuration("watch")
+		if err != nil {
+			return withExitCode(exitConfigError, fmt.Errorf("error in watch interval: %w", err))
+		}
+		if watch < 0 {
+			return withExitCode(exitConfigError, fmt.Errorf("invalid watch interval: %s, must be positive", watch))
+		}
+
+		cfg, tfPluginClient, err := loadConfigAndSetup(cmd)
+		if err != nil {
+			return err
+		}
+
+		if watch == 0 {
+			err = reconcile(cmd, cfg, tfPluginClient, dryRun)
+			if cmd.Context().Err() != nil {
+				return withExitCode(exitInterrupted, errInterrupted)
+			}
+			return err
+		}
+
+		ticker := time.NewTicker(watch)
+		defer ticker.Stop()
+
+		for {
+			if err := reconcile(cmd, cfg, tfPluginClient, dryRun); err != nil && cmd.Context().Err() == nil {
+				log.Error().Err(err).Msg("reconciliation failed")
+			}
+
+			select {
+			case <-cmd.Context().Done():
+				log.Info().Msg("stopping reconciler")
+				return nil
+			case <-ticker.C:
+			}
+		}
+	},
+}
+
+func init() {
+	reconcileCmd.Flags().Bool("dry-run", false, "only print the changes needed on every farm")
+	reconcileCmd.Flags().Duration("watch", 0, "keep running and reconcile the farms every interval, e.g. 30m")
+}
+
+// reconcile runs a reconciliation pass and prints the changes of every farm
+func reconcile(cmd *cobra.Command, cfg spawner.Config, tfPluginClient deployer.TFPluginClient, dryRun bool) error {
+	result, err := spawner.Reconcile(cmd.Context(), cfg, tfPluginClient, spawner.ReconcileOptions{DryRun: dryRun, Exclude: quarantinedNodes(cmd)})
+	if printErr := printReconcileResult(cmd.OutOrStdout(), result); printErr != nil {
+		log.Error().Err(printErr).Msg("failed to print reconcile result")
+	}
+	if err == nil {
+		return nil
+	}
+
+	failed := 0
+	for _, farm := range result.Farms {
+		if farm.Error != "" {
+			failed++
+		}
+	}
+
+	return withExitCode(failureExitCode(len(result.Farms), failed), fmt.Errorf("failed to reconcile farms: %w", err))
+}
+
+// printReconcileResult writes the changes of a reconciliation pass as a table
+func printReconcileResult(w io.Writer, result spawner.ReconcileResult) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "Farm\tDesired\tKept\tRemove\tDeploy\tDeployed\tError")
+	for _, farm := range result.Farms {
+		deployed := 0
+		for _, node := range farm.Deployed {
+			if node.Succeeded() {
+				deployed++
+			}
+		}
+		fmt.Fprintf(
+			tw, "%d\t%d\t%v\t%v\t%v\t%d\t%s\n",
+			farm.Farm, farm.Desired, farm.Kept, farm.Remove, farm.Deploy, deployed, farm.Error,
+		)
+	}
+	return tw.Flush()
+}
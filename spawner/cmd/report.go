@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/threefoldtech/guardians_healthchecker/spawner/internal/analysis"
+	"github.com/threefoldtech/guardians_healthchecker/spawner/internal/report"
+)
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "print the health scorecard of every farm of a run",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return withExitCode(exitConfigError, fmt.Errorf("error in output format: %w", err))
+		}
+		if output != report.MarkdownFormat && output != report.HTMLFormat {
+			return withExitCode(exitConfigError, fmt.Errorf("unsupported output format '%s', should be %s or %s", output, report.MarkdownFormat, report.HTMLFormat))
+		}
+
+		run, nodes, profiles, err := runScores(cmd)
+		if err != nil {
+			return err
+		}
+
+		scorecard := report.NewScorecard(run, nodes, analysis.FindAnomalies(nodes, profiles))
+		return report.WriteScorecard(cmd.OutOrStdout(), output, scorecard)
+	},
+}
+
+func init() {
+	reportCmd.Flags().String("run", "", "ID of the run to report on")
+	reportCmd.Flags().StringP("output", "o", report.MarkdownFormat, "output format of the scorecard: markdown or html")
+}
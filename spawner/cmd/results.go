@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/threefoldtech/guardians_healthchecker/spawner/internal/analysis"
+	"github.com/threefoldtech/guardians_healthchecker/spawner/internal/history"
+	"github.com/threefoldtech/guardians_healthchecker/spawner/internal/influx"
+	"github.com/threefoldtech/guardians_healthchecker/spawner/internal/results"
+	spawner "github.com/threefoldtech/guardians_healthchecker/spawner/pkg/spawner"
+)
+
+var resultsCmd = &cobra.Command{
+	Use:   "results",
+	Short: "collect the benchmark results of a run from InfluxDB",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return withExitCode(exitConfigError, fmt.Errorf("error in output format: %w", err))
+		}
+		if output != "table" && output != "json" && output != "csv" {
+			return withExitCode(exitConfigError, fmt.Errorf("unsupported output format '%s', should be table, json or csv", output))
+		}
+
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			return err
+		}
+		run, store, err := loadRun(cmd)
+		if err != nil {
+			return err
+		}
+
+		nodes, err := collectScores(cmd, cfg, store, run)
+		if err != nil {
+			return err
+		}
+
+		return printScores(cmd.OutOrStdout(), nodes, output)
+	},
+}
+
+func init() {
+	resultsCmd.Flags().String("run", "", "ID of the run to collect the results of")
+	resultsCmd.Flags().StringP("output", "o", "table", "output format of the results: table, json or csv")
+}
+
+// printScores writes the benchmark scores of every node in the given format
+func printScores(w io.Writer, nodes []results.NodeScores, format string) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(nodes)
+
+	case "csv":
+		cw := csv.NewWriter(w)
+		_ = cw.Write(append([]string{"farm", "node", "deployed"}, influx.Categories...))
+		for _, node := range nodes {
+			row := []string{strconv.FormatUint(node.Farm, 10), strconv.FormatUint(uint64(node.Node), 10), strconv.FormatBool(node.Deployed)}
+			for _, category := range influx.Categories {
+				row = append(row, formatScore(node, category, ""))
+			}
+			_ = cw.Write(row)
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "Farm\tNode\tDeployed\tCPU\tMemory\tDisk\tNetwork")
+	for _, node := range nodes {
+		fmt.Fprintf(tw, "%d\t%d\t%t", node.Farm, node.Node, node.Deployed)
+		for _, category := range influx.Categories {
+			fmt.Fprintf(tw, "\t%s", formatScore(node, category, "-"))
+		}
+		fmt.Fprintln(tw)
+	}
+	return tw.Flush()
+}
+
+// formatScore formats the score of a node in a category, missing scores are formatted as missing
+func formatScore(node results.NodeScores, category, missing string) string {
+	score, ok := node.Scores[category]
+	if !ok {
+		return missing
+	}
+	return strconv.FormatFloat(score, 'f', 2, 64)
+}
+
+// collectScores collects the benchmark scores of the run and records them with the run in the history
+func collectScores(cmd *cobra.Command, cfg spawner.Config, store *history.Store, run history.Run) ([]results.NodeScores, error) {
+	nodes, err := results.Collect(cmd.Context(), influx.New(cfg.Influx), run)
+	if err != nil {
+		return nil, withExitCode(exitGridError, err)
+	}
+
+	scores := make(map[uint32]map[string]float64)
+	for _, node := range nodes {
+		if node.Reported() {
+			scores[node.Node] = node.Scores
+		}
+	}
+	saveErr := store.Update(run.ID, func(run *history.Run) error {
+		run.Scores = scores
+		return nil
+	})
+	if saveErr != nil {
+		log.Warn().Err(saveErr).Msg("failed to save the run scores")
+	}
+
+	return nodes, nil
+}
+
+// runScores collects the benchmark scores of the run of the --run flag along with the hardware profiles of its nodes
+func runScores(cmd *cobra.Command) (history.Run, []results.NodeScores, map[uint32]string, error) {
+	cfg, tfPluginClient, err := loadConfigAndSetup(cmd)
+	if err != nil {
+		return history.Run{}, nil, nil, err
+	}
+	run, store, err := loadRun(cmd)
+	if err != nil {
+		return history.Run{}, nil, nil, err
+	}
+
+	nodes, err := collectScores(cmd, cfg, store, run)
+	if err != nil {
+		return history.Run{}, nil, nil, err
+	}
+
+	// the profiles come from the node records snapshotted by the run, only the nodes of older runs are looked up
+	records := run.NodeRecords()
+	var missing []uint32
+	for _, node := range nodes {
+		if _, ok := records[node.Node]; !ok {
+			missing = append(missing, node.Node)
+		}
+	}
+	looked, err := spawner.LookupNodes(cmd.Context(), tfPluginClient, missing)
+	if err != nil {
+		return history.Run{}, nil, nil, withExitCode(exitGridError, err)
+	}
+	maps.Copy(records, looked)
+
+	profiles := make(map[uint32]string, len(records))
+	for id, record := range records {
+		profiles[id] = analysis.Profile(record)
+	}
+
+	return run, nodes, profiles, nil
+}
@@ -1,7 +1,10 @@
 package cmd
 
 import (
+	"context"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -10,8 +13,10 @@ import (
 )
 
 var rootCmd = &cobra.Command{
-	Use:   "spawner",
-	Short: "tool used for spawning and destroying benchmark VMs",
+	Use:           "spawner",
+	Short:         "tool used for spawning and destroying benchmark VMs",
+	SilenceUsage:  true,
+	SilenceErrors: true,
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -22,13 +27,31 @@ func Execute() {
 	rootCmd.AddCommand(spawnCmd)
 	rootCmd.AddCommand(destroyCmd)
 	rootCmd.AddCommand(listCmd)
+	rootCmd.AddCommand(statusCmd)
+	rootCmd.AddCommand(gcCmd)
+	rootCmd.AddCommand(reapCmd)
+	rootCmd.AddCommand(daemonCmd)
+	rootCmd.AddCommand(reconcileCmd)
+	rootCmd.AddCommand(serveCmd)
+	rootCmd.AddCommand(resultsCmd)
+	rootCmd.AddCommand(silentCmd)
+	rootCmd.AddCommand(baselineCmd)
+	rootCmd.AddCommand(anomaliesCmd)
+	rootCmd.AddCommand(reportCmd)
+	rootCmd.AddCommand(runsCmd)
+	rootCmd.AddCommand(quarantineCmd)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := rootCmd.ExecuteContext(ctx)
+	stop()
 
-	err := rootCmd.Execute()
 	if err != nil {
-		log.Fatal().Err(err).Send()
+		log.Error().Err(err).Send()
 	}
+	os.Exit(exitCode(err))
 }
 
 func init() {
 	rootCmd.PersistentFlags().StringP("config", "c", "", "path to config file")
+	rootCmd.PersistentFlags().String("state-dir", "", "directory where the run history is kept (default: $HOME/.spawner)")
 }
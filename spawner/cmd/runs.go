@@ -0,0 +1,280 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/threefoldtech/guardians_healthchecker/spawner/internal/history"
+	"github.com/threefoldtech/guardians_healthchecker/spawner/internal/influx"
+)
+
+var runsCmd = &cobra.Command{
+	Use:   "runs",
+	Short: "browse the run history without querying the grid or InfluxDB",
+}
+
+var runsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list the recorded runs",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		campaign, err := cmd.Flags().GetString("campaign")
+		if err != nil {
+			return withExitCode(exitConfigError, fmt.Errorf("error in campaign: %w", err))
+		}
+		since, err := cmd.Flags().GetDuration("since")
+		if err != nil {
+			return withExitCode(exitConfigError, fmt.Errorf("error in since: %w", err))
+		}
+		if since < 0 {
+			return withExitCode(exitConfigError, fmt.Errorf("invalid since: %s, must be positive", since))
+		}
+
+		store, err := openHistory(cmd)
+		if err != nil {
+			return err
+		}
+		runs, err := store.List()
+		if err != nil {
+			return withExitCode(exitGenericError, err)
+		}
+
+		var filtered []history.Run
+		for _, run := range runs {
+			if campaign != "" && run.Campaign != campaign {
+				continue
+			}
+			if since != 0 && time.Since(run.StartedAt) > since {
+				continue
+			}
+			filtered = append(filtered, run)
+		}
+
+		return printRuns(cmd.OutOrStdout(), filtered)
+	},
+}
+
+var runsShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "show the configuration, node outcomes and scores of a recorded run",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return withExitCode(exitConfigError, fmt.Errorf("error in output format: %w", err))
+		}
+		if output != "table" && output != "json" {
+			return withExitCode(exitConfigError, fmt.Errorf("unsupported output format '%s', should be table or json", output))
+		}
+
+		run, _, err := loadRun(cmd)
+		if err != nil {
+			return err
+		}
+
+		return printRun(cmd.OutOrStdout(), run, output)
+	},
+}
+
+var runsDiffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "show the nodes whose health, eligibility or scores changed between two recorded runs",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fromID, err := cmd.Flags().GetString("from")
+		if err != nil {
+			return withExitCode(exitConfigError, fmt.Errorf("error in from run ID: %w", err))
+		}
+		toID, err := cmd.Flags().GetString("to")
+		if err != nil {
+			return withExitCode(exitConfigError, fmt.Errorf("error in to run ID: %w", err))
+		}
+		if fromID == "" || toID == "" {
+			return withExitCode(exitConfigError, errors.New("both --from and --to run IDs are required"))
+		}
+		minChange, err := cmd.Flags().GetFloat64("min-change")
+		if err != nil {
+			return withExitCode(exitConfigError, fmt.Errorf("error in min-change: %w", err))
+		}
+		if minChange < 0 {
+			return withExitCode(exitConfigError, fmt.Errorf("invalid min-change: %v, must be positive", minChange))
+		}
+		output, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return withExitCode(exitConfigError, fmt.Errorf("error in output format: %w", err))
+		}
+		if output != "table" && output != "json" {
+			return withExitCode(exitConfigError, fmt.Errorf("unsupported output format '%s', should be table or json", output))
+		}
+
+		store, err := openHistory(cmd)
+		if err != nil {
+			return err
+		}
+		from, err := store.Get(fromID)
+		if err != nil {
+			return withExitCode(exitConfigError, err)
+		}
+		to, err := store.Get(toID)
+		if err != nil {
+			return withExitCode(exitConfigError, err)
+		}
+
+		diff := history.Diff(from, to)
+		diff.Scores = slices.DeleteFunc(diff.Scores, func(delta history.ScoreDelta) bool {
+			return math.Abs(delta.Change)*100 < minChange
+		})
+
+		return printDiff(cmd.OutOrStdout(), diff, output)
+	},
+}
+
+func init() {
+	runsListCmd.Flags().String("campaign", "", "only list the runs of the campaign")
+	runsListCmd.Flags().Duration("since", 0, "only list the runs started within this duration, e.g. 168h")
+
+	runsShowCmd.Flags().String("run", "", "ID of the run to show")
+	runsShowCmd.Flags().StringP("output", "o", "table", "output format of the run: table or json")
+
+	runsDiffCmd.Flags().String("from", "", "ID of the earlier run")
+	runsDiffCmd.Flags().String("to", "", "ID of the later run")
+	runsDiffCmd.Flags().Float64("min-change", 10, "smallest score change in percent to show")
+	runsDiffCmd.Flags().StringP("output", "o", "table", "output format of the diff: table or json")
+
+	runsCmd.AddCommand(runsListCmd)
+	runsCmd.AddCommand(runsShowCmd)
+	runsCmd.AddCommand(runsDiffCmd)
+}
+
+// printRuns writes the runs as a table
+func printRuns(w io.Writer, runs []history.Run) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "ID\tCampaign\tStatus\tStarted\tFarms\tSucceeded\tFailed\tReported")
+	for _, run := range runs {
+		succeeded, failed, reported := "-", "-", "-"
+		if run.Spawn != nil {
+			s, f := run.Spawn.Counts()
+			succeeded, failed = fmt.Sprint(s), fmt.Sprint(f)
+		}
+		if run.Scores != nil {
+			reported = fmt.Sprint(len(run.Scores))
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			run.ID, valueOrDash(run.Campaign), run.Status, run.StartedAt.Format(time.RFC3339), joinFarms(run.Farms), succeeded, failed, reported)
+	}
+	return tw.Flush()
+}
+
+// printRun writes the run in the given format
+func printRun(w io.Writer, run history.Run, format string) error {
+	if format == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(run)
+	}
+
+	fmt.Fprintf(w, "Run:      %s\n", run.ID)
+	fmt.Fprintf(w, "Campaign: %s\n", valueOrDash(run.Campaign))
+	fmt.Fprintf(w, "Status:   %s\n", run.Status)
+	fmt.Fprintf(w, "Started:  %s\n", run.StartedAt.Format(time.RFC3339))
+	if !run.FinishedAt.IsZero() {
+		fmt.Fprintf(w, "Finished: %s\n", run.FinishedAt.Format(time.RFC3339))
+	}
+	fmt.Fprintf(w, "Farms:    %s\n", joinFarms(run.Farms))
+	if run.Config != nil {
+		fmt.Fprintf(w, "Strategy: deployment %v, failure %s\n", run.Config.DeploymentStrategy, run.Config.FailureStrategy)
+	}
+	if run.Error != "" {
+		fmt.Fprintf(w, "Error:    %s\n", run.Error)
+	}
+	if run.Spawn == nil {
+		return nil
+	}
+
+	states := run.NodeStates()
+	fmt.Fprintln(w)
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "Farm\tNode\tState\tAttempts\tDuration\tCPU\tMemory\tDisk\tNetwork\tError")
+	for _, farm := range run.Spawn.Farms {
+		if len(farm.Nodes) == 0 {
+			fmt.Fprintf(tw, "%d\t-\t-\t-\t-\t-\t-\t-\t-\t%s\n", farm.Farm, farm.Error)
+		}
+		for _, node := range farm.Nodes {
+			fmt.Fprintf(tw, "%d\t%d\t%s\t%d\t%s", farm.Farm, node.Node, states[node.Node], node.Attempts, node.Duration.Round(time.Second))
+			for _, category := range influx.Categories {
+				score, ok := run.Scores[node.Node][category]
+				if ok {
+					fmt.Fprintf(tw, "\t%.2f", score)
+				} else {
+					fmt.Fprint(tw, "\t-")
+				}
+			}
+			fmt.Fprintf(tw, "\t%s\n", node.Error)
+		}
+	}
+	return tw.Flush()
+}
+
+// printDiff writes the state changes, the nodes which dropped out of eligibility and the score changes between two runs in the given format
+func printDiff(w io.Writer, diff history.RunDiff, format string) error {
+	if format == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(diff)
+	}
+
+	if len(diff.Changes) == 0 && len(diff.Ineligible) == 0 && len(diff.Scores) == 0 {
+		_, err := fmt.Fprintf(w, "nothing changed between runs %s and %s\n", diff.From, diff.To)
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	if len(diff.Changes) != 0 {
+		fmt.Fprintf(tw, "Farm\tNode\t%s\t%s\n", diff.From, diff.To)
+		for _, change := range diff.Changes {
+			fmt.Fprintf(tw, "%d\t%d\t%s\t%s\n", change.Farm, change.Node, change.From, change.To)
+		}
+	}
+	if len(diff.Ineligible) != 0 {
+		if len(diff.Changes) != 0 {
+			fmt.Fprintln(tw)
+		}
+		fmt.Fprintln(tw, "Farm\tNode\tEligibility")
+		for _, node := range diff.Ineligible {
+			fmt.Fprintf(tw, "%d\t%d\tdropped\n", node.Farm, node.Node)
+		}
+	}
+	if len(diff.Scores) != 0 {
+		if len(diff.Changes) != 0 || len(diff.Ineligible) != 0 {
+			fmt.Fprintln(tw)
+		}
+		fmt.Fprintf(tw, "Farm\tNode\tCategory\t%s\t%s\tChange\n", diff.From, diff.To)
+		for _, delta := range diff.Scores {
+			fmt.Fprintf(tw, "%d\t%d\t%s\t%.2f\t%.2f\t%+.0f%%\n", delta.Farm, delta.Node, delta.Category, delta.From, delta.To, delta.Change*100)
+		}
+	}
+	return tw.Flush()
+}
+
+// joinFarms formats the farm IDs as a comma separated list
+func joinFarms(farms []uint64) string {
+	ids := make([]string, 0, len(farms))
+	for _, farm := range farms {
+		ids = append(ids, fmt.Sprint(farm))
+	}
+	return strings.Join(ids, ",")
+}
+
+// valueOrDash returns the value or a dash if it is empty
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
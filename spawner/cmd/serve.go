@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/threefoldtech/guardians_healthchecker/spawner/internal/metrics"
+	"github.com/threefoldtech/guardians_healthchecker/spawner/internal/server"
+)
+
+// tokenEnv is the environment variable holding the API token when --token is not set
+const tokenEnv = "SPAWNER_API_TOKEN"
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "serve a REST API for triggering and inspecting runs",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		listen, err := cmd.Flags().GetString("listen")
+		if err != nil {
+			return withExitCode(exitConfigError, fmt.Errorf("error in listen address: %w", err))
+		}
+		token, err := cmd.Flags().GetString("token")
+		if err != nil {
+			return withExitCode(exitConfigError, fmt.Errorf("error in API token: %w", err))
+		}
+		if token == "" {
+			token = os.Getenv(tokenEnv)
+		}
+		if token == "" {
+			return withExitCode(exitConfigError, fmt.Errorf("an API token is required, set --token or %s", tokenEnv))
+		}
+
+		cfg, tfPluginClient, err := loadConfigAndSetup(cmd)
+		if err != nil {
+			return err
+		}
+		store, err := openHistory(cmd)
+		if err != nil {
+			return err
+		}
+
+		m := metrics.New()
+		watchInventory(cmd.Context(), m, cfg, tfPluginClient)
+
+		return server.New(cfg, tfPluginClient, store, m, token).Run(cmd.Context(), listen)
+	},
+}
+
+func init() {
+	serveCmd.Flags().String("listen", ":8080", "address the API listens on")
+	serveCmd.Flags().String("token", "", "bearer token required by the API (default: $"+tokenEnv+")")
+}
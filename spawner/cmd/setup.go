@@ -1,11 +1,14 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 
+	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
+	"github.com/threefoldtech/guardians_healthchecker/spawner/internal/history"
 	"github.com/threefoldtech/guardians_healthchecker/spawner/internal/parser"
 	spawner "github.com/threefoldtech/guardians_healthchecker/spawner/pkg/spawner"
 	"github.com/threefoldtech/tfgrid-sdk-go/grid-client/deployer"
@@ -29,39 +32,120 @@ func setup(cfg spawner.Config) (deployer.TFPluginClient, error) {
 
 // loadConfigAndSetup loads and parses the configuration file, sets up the tfPluginClient, and returns the context, config, and client.
 func loadConfigAndSetup(cmd *cobra.Command) (spawner.Config, deployer.TFPluginClient, error) {
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return spawner.Config{}, deployer.TFPluginClient{}, err
+	}
+
+	tfPluginClient, err := setup(cfg)
+	if err != nil {
+		return spawner.Config{}, deployer.TFPluginClient{}, withExitCode(exitGridError, fmt.Errorf("failed to connect to the grid: %w", err))
+	}
+
+	return cfg, tfPluginClient, nil
+}
+
+// loadConfig loads and parses the configuration file, for commands which do not talk to the grid.
+func loadConfig(cmd *cobra.Command) (spawner.Config, error) {
 	if len(cmd.Flags().Args()) != 0 {
-		return spawner.Config{}, deployer.TFPluginClient{}, fmt.Errorf("command '%s' does not support additional arguments: %v", cmd.Name(), cmd.Flags().Args())
+		return spawner.Config{}, withExitCode(exitConfigError, fmt.Errorf("command '%s' does not support additional arguments: %v", cmd.Name(), cmd.Flags().Args()))
 	}
 
 	configPath, err := cmd.Flags().GetString("config")
 	if err != nil {
-		return spawner.Config{}, deployer.TFPluginClient{}, fmt.Errorf("error in configuration file path: %w", err)
+		return spawner.Config{}, withExitCode(exitConfigError, fmt.Errorf("error in configuration file path: %w", err))
 	}
 
 	if configPath == "" {
-		return spawner.Config{}, deployer.TFPluginClient{}, fmt.Errorf("required configuration file path is empty")
+		return spawner.Config{}, withExitCode(exitConfigError, fmt.Errorf("required configuration file path is empty"))
 	}
 
 	configFile, err := os.Open(configPath)
 	if err != nil {
-		return spawner.Config{}, deployer.TFPluginClient{}, fmt.Errorf("failed to open configuration file '%s' with error: %w", configPath, err)
+		return spawner.Config{}, withExitCode(exitConfigError, fmt.Errorf("failed to open configuration file '%s' with error: %w", configPath, err))
 	}
 	defer configFile.Close()
 
 	yamlFmt := filepath.Ext(configPath) == ".yaml"
 	if !yamlFmt {
-		return spawner.Config{}, deployer.TFPluginClient{}, fmt.Errorf("unsupported configuration file format '%s', should be .yaml", configPath)
+		return spawner.Config{}, withExitCode(exitConfigError, fmt.Errorf("unsupported configuration file format '%s', should be .yaml", configPath))
 	}
 
 	cfg, err := parser.ParseConfig(configFile)
 	if err != nil {
-		return spawner.Config{}, deployer.TFPluginClient{}, fmt.Errorf("failed to parse configuration file '%s' with error: %w", configPath, err)
+		return spawner.Config{}, withExitCode(exitConfigError, fmt.Errorf("failed to parse configuration file '%s' with error: %w", configPath, err))
 	}
 
-	tfPluginClient, err := setup(cfg)
+	return cfg, nil
+}
+
+// stateDir returns the state directory of the --state-dir flag, $HOME/.spawner by default
+func stateDir(cmd *cobra.Command) (string, error) {
+	dir, err := cmd.Flags().GetString("state-dir")
 	if err != nil {
-		return spawner.Config{}, deployer.TFPluginClient{}, err
+		return "", withExitCode(exitConfigError, fmt.Errorf("error in state directory: %w", err))
 	}
 
-	return cfg, tfPluginClient, nil
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", withExitCode(exitConfigError, fmt.Errorf("failed to find the default state directory: %w", err))
+		}
+		dir = filepath.Join(home, ".spawner")
+	}
+
+	return dir, nil
+}
+
+// openHistory opens the run history kept in the state directory
+func openHistory(cmd *cobra.Command) (*history.Store, error) {
+	dir, err := stateDir(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := history.NewStore(filepath.Join(dir, "history.db"))
+	if err != nil {
+		return nil, withExitCode(exitConfigError, err)
+	}
+
+	return store, nil
+}
+
+// quarantinedNodes returns the nodes currently quarantined, failing to read them only logs a warning
+// so a broken history does not stop the runs
+func quarantinedNodes(cmd *cobra.Command) []uint32 {
+	store, err := openHistory(cmd)
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to open the run history, no node is excluded")
+		return nil
+	}
+
+	return store.ExcludedNodes()
+}
+
+// loadRun returns the run of the --run flag from the run history, along with the history
+func loadRun(cmd *cobra.Command) (history.Run, *history.Store, error) {
+	runID, err := cmd.Flags().GetString("run")
+	if err != nil {
+		return history.Run{}, nil, withExitCode(exitConfigError, fmt.Errorf("error in run ID: %w", err))
+	}
+	if runID == "" {
+		return history.Run{}, nil, withExitCode(exitConfigError, errors.New("required run ID is empty"))
+	}
+
+	store, err := openHistory(cmd)
+	if err != nil {
+		return history.Run{}, nil, err
+	}
+
+	run, err := store.Get(runID)
+	if errors.Is(err, history.ErrRunNotFound) {
+		return history.Run{}, nil, withExitCode(exitConfigError, err)
+	}
+	if err != nil {
+		return history.Run{}, nil, err
+	}
+
+	return run, store, nil
 }
@@ -0,0 +1,261 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/threefoldtech/guardians_healthchecker/spawner/internal/history"
+	"github.com/threefoldtech/guardians_healthchecker/spawner/internal/influx"
+	"github.com/threefoldtech/guardians_healthchecker/spawner/internal/results"
+	spawner "github.com/threefoldtech/guardians_healthchecker/spawner/pkg/spawner"
+)
+
+var silentCmd = &cobra.Command{
+	Use:   "silent",
+	Short: "report the nodes of a run whose benchmark never wrote to InfluxDB",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		grace, err := cmd.Flags().GetDuration("grace")
+		if err != nil {
+			return withExitCode(exitConfigError, fmt.Errorf("error in grace period: %w", err))
+		}
+		if grace < 0 {
+			return withExitCode(exitConfigError, fmt.Errorf("invalid grace period: %s, must be positive", grace))
+		}
+		redeploy, err := cmd.Flags().GetBool("redeploy")
+		if err != nil {
+			return withExitCode(exitConfigError, fmt.Errorf("error in redeploy: %w", err))
+		}
+		yes, err := cmd.Flags().GetBool("yes")
+		if err != nil {
+			return withExitCode(exitConfigError, fmt.Errorf("error in yes: %w", err))
+		}
+
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			return err
+		}
+		run, store, err := loadRun(cmd)
+		if err != nil {
+			return err
+		}
+
+		if waited := time.Since(results.DeployedAt(run)); waited < grace {
+			return fmt.Errorf("the grace period of run %s is not over, check again in %s", run.ID, (grace - waited).Round(time.Second))
+		}
+
+		silent, err := results.FindSilent(cmd.Context(), influx.New(cfg.Influx), run)
+		if err != nil {
+			return withExitCode(exitGridError, err)
+		}
+		if len(silent) == 0 {
+			log.Info().Str("Run", run.ID).Msg("all deployed nodes are reporting")
+			return nil
+		}
+		if printErr := printSilentNodes(cmd.OutOrStdout(), silent); printErr != nil {
+			log.Error().Err(printErr).Msg("failed to print silent nodes")
+		}
+
+		if !redeploy {
+			succeeded, _ := run.Spawn.Counts()
+			return withExitCode(failureExitCode(succeeded, len(silent)), fmt.Errorf("%d of %d deployed nodes are silent", len(silent), succeeded))
+		}
+
+		if !yes {
+			question := fmt.Sprintf("destroy and redeploy the VMs on %d silent nodes?", len(silent))
+			confirmed, err := confirm(cmd.InOrStdin(), cmd.OutOrStdout(), question)
+			if err != nil {
+				return withExitCode(exitGenericError, err)
+			}
+			if !confirmed {
+				log.Info().Msg("redeploy aborted")
+				return nil
+			}
+		}
+
+		return redeploySilent(cmd, cfg, store, run, silent)
+	},
+}
+
+func init() {
+	silentCmd.Flags().String("run", "", "ID of the run to check")
+	silentCmd.Flags().Duration("grace", 30*time.Minute, "time given to the benchmarks to report after the VMs are deployed")
+	silentCmd.Flags().Bool("redeploy", false, "destroy and redeploy the VMs on the silent nodes")
+	silentCmd.Flags().BoolP("yes", "y", false, "redeploy without asking for confirmation")
+}
+
+// skippedNode is a silent node which was not redeployed, along with the reason
+type skippedNode struct {
+	results.SilentNode
+	Reason string
+}
+
+// redeploySilent destroys the recorded contracts of the silent nodes, deploys them again as part of the same run and records them in the history.
+// Nodes whose contracts could not be destroyed are not redeployed, they are reported along with the nodes the spawn dropped.
+func redeploySilent(cmd *cobra.Command, cfg spawner.Config, store *history.Store, run history.Run, silent []results.SilentNode) error {
+	_, tfPluginClient, err := loadConfigAndSetup(cmd)
+	if err != nil {
+		return err
+	}
+
+	cfg.Farms = nil
+	var nodes []uint32
+	for _, node := range silent {
+		if len(cfg.Farms) == 0 || cfg.Farms[len(cfg.Farms)-1] != node.Farm {
+			cfg.Farms = append(cfg.Farms, node.Farm)
+		}
+		nodes = append(nodes, node.Node)
+	}
+
+	opts := run.DestroyOptions()
+	opts.Nodes = nodes
+	destroyed, err := spawner.Destroy(cmd.Context(), cfg, tfPluginClient, opts)
+	if cmd.Context().Err() != nil {
+		return withExitCode(exitInterrupted, errInterrupted)
+	}
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to destroy some of the silent VMs")
+	}
+
+	skipped := undestroyedNodes(run, silent, destroyed)
+	nodes = slices.DeleteFunc(nodes, func(node uint32) bool {
+		return slices.ContainsFunc(skipped, func(s skippedNode) bool { return s.Node == node })
+	})
+	if len(nodes) == 0 {
+		if printErr := printSkippedNodes(cmd.OutOrStdout(), skipped); printErr != nil {
+			log.Error().Err(printErr).Msg("failed to print skipped nodes")
+		}
+		destroyErr := fmt.Errorf("failed to destroy the VMs of all %d silent nodes", len(silent))
+		return withExitCode(exitGridError, multierror.Append(destroyErr, err))
+	}
+
+	result, err := spawner.Spawn(cmd.Context(), cfg, tfPluginClient, spawner.SpawnOptions{RunID: run.ID, Nodes: nodes})
+	if telemetryErr := influx.New(cfg.Influx).WriteSpawn(context.WithoutCancel(cmd.Context()), result); telemetryErr != nil {
+		log.Warn().Err(telemetryErr).Msg("failed to write deployment telemetry")
+	}
+
+	saveErr := store.Update(run.ID, func(run *history.Run) error {
+		run.MergeSpawn(result)
+		return nil
+	})
+	if saveErr != nil {
+		log.Warn().Err(saveErr).Msg("failed to save the run")
+	}
+	store.TrackOutcomes(cmd.Context(), result, cfg.Quarantine)
+	if printErr := printSpawnResult(cmd.OutOrStdout(), result, "table"); printErr != nil {
+		log.Error().Err(printErr).Msg("failed to print spawn result")
+	}
+
+	skipped = append(skipped, droppedNodes(silent, nodes, result)...)
+	err = spawnError(cmd.Context(), result, err)
+	if len(skipped) == 0 || cmd.Context().Err() != nil {
+		return err
+	}
+
+	if printErr := printSkippedNodes(cmd.OutOrStdout(), skipped); printErr != nil {
+		log.Error().Err(printErr).Msg("failed to print skipped nodes")
+	}
+	succeeded, _ := result.Counts()
+	skippedErr := fmt.Errorf("%d of %d silent nodes were not redeployed", len(skipped), len(silent))
+	return withExitCode(failureExitCode(len(silent), len(silent)-succeeded), multierror.Append(err, skippedErr))
+}
+
+// undestroyedNodes returns the silent nodes whose recorded contracts could not be cancelled, or could not be verified
+// on their unreachable node. Contracts left on a farm which were not recorded for a silent node skip all the silent nodes of the farm.
+func undestroyedNodes(run history.Run, silent []results.SilentNode, destroyed spawner.DestroyResult) []skippedNode {
+	owners := make(map[uint64]uint32)
+	if run.Spawn != nil {
+		for _, farm := range run.Spawn.Farms {
+			for _, node := range farm.Nodes {
+				for _, contract := range node.Contracts() {
+					owners[contract] = node.Node
+				}
+			}
+		}
+	}
+
+	reasons := make(map[uint32]string)
+	skipFarm := func(farm uint64, reason string) {
+		for _, node := range silent {
+			if _, ok := reasons[node.Node]; node.Farm == farm && !ok {
+				reasons[node.Node] = reason
+			}
+		}
+	}
+	skipContracts := func(farm uint64, contracts []uint64, reason string) {
+		for _, contract := range contracts {
+			node, ok := owners[contract]
+			if !ok {
+				skipFarm(farm, fmt.Sprintf("%s %d", reason, contract))
+				continue
+			}
+			if _, ok := reasons[node]; !ok {
+				reasons[node] = fmt.Sprintf("%s %d", reason, contract)
+			}
+		}
+	}
+
+	for _, farm := range destroyed.Farms {
+		if farm.Error != "" && len(farm.Cancelled) == 0 && len(farm.Remaining) == 0 {
+			skipFarm(farm.Farm, fmt.Sprintf("failed to destroy: %s", farm.Error))
+			continue
+		}
+		skipContracts(farm.Farm, farm.Remaining, "could not cancel contract")
+		skipContracts(farm.Farm, farm.Unverified, "could not verify contract")
+	}
+
+	var skipped []skippedNode
+	for _, node := range silent {
+		if reason, ok := reasons[node.Node]; ok {
+			skipped = append(skipped, skippedNode{SilentNode: node, Reason: reason})
+		}
+	}
+
+	return skipped
+}
+
+// droppedNodes returns the silent nodes which were to be redeployed but are missing from the spawn result,
+// as they were no longer eligible or their farm could not be looked up
+func droppedNodes(silent []results.SilentNode, nodes []uint32, result spawner.SpawnResult) []skippedNode {
+	deployed := make(map[uint32]bool)
+	for _, farm := range result.Farms {
+		for _, node := range farm.Nodes {
+			deployed[node.Node] = true
+		}
+	}
+
+	var dropped []skippedNode
+	for _, node := range silent {
+		if slices.Contains(nodes, node.Node) && !deployed[node.Node] {
+			dropped = append(dropped, skippedNode{SilentNode: node, Reason: "not eligible for deployment"})
+		}
+	}
+
+	return dropped
+}
+
+// printSilentNodes writes the silent nodes as a table
+func printSilentNodes(w io.Writer, silent []results.SilentNode) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "Farm\tNode\tVMContract")
+	for _, node := range silent {
+		fmt.Fprintf(tw, "%d\t%d\t%d\n", node.Farm, node.Node, node.VMContractID)
+	}
+	return tw.Flush()
+}
+
+// printSkippedNodes writes the silent nodes which were not redeployed as a table
+func printSkippedNodes(w io.Writer, skipped []skippedNode) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "Farm\tNode\tVMContract\tNotRedeployed")
+	for _, node := range skipped {
+		fmt.Fprintf(tw, "%d\t%d\t%d\t%s\n", node.Farm, node.Node, node.VMContractID, node.Reason)
+	}
+	return tw.Flush()
+}
@@ -2,9 +2,18 @@ package cmd
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
 
+	"github.com/hashicorp/go-multierror"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
+	"github.com/threefoldtech/guardians_healthchecker/spawner/internal/history"
+	"github.com/threefoldtech/guardians_healthchecker/spawner/internal/influx"
+	"github.com/threefoldtech/guardians_healthchecker/spawner/internal/report"
 	spawner "github.com/threefoldtech/guardians_healthchecker/spawner/pkg/spawner"
 )
 
@@ -12,15 +21,177 @@ var spawnCmd = &cobra.Command{
 	Use:   "spawn",
 	Short: "spawn VMs on all nodes in a list of farms",
 	RunE: func(cmd *cobra.Command, args []string) error {
+		output, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return withExitCode(exitConfigError, fmt.Errorf("error in output format: %w", err))
+		}
+		if output != "table" && output != "json" {
+			return withExitCode(exitConfigError, fmt.Errorf("unsupported output format '%s', should be table or json", output))
+		}
+
+		reportPath, err := cmd.Flags().GetString("report")
+		if err != nil {
+			return withExitCode(exitConfigError, fmt.Errorf("error in report file path: %w", err))
+		}
+		reportFormat, err := cmd.Flags().GetString("report-format")
+		if err != nil {
+			return withExitCode(exitConfigError, fmt.Errorf("error in report format: %w", err))
+		}
+		if reportFormat == "" {
+			reportFormat = report.FormatFromPath(reportPath)
+		}
+		if reportFormat != report.JSONFormat && reportFormat != report.JUnitFormat {
+			return withExitCode(exitConfigError, fmt.Errorf("unsupported report format '%s', should be %s or %s", reportFormat, report.JSONFormat, report.JUnitFormat))
+		}
+
+		wait, err := waitOptions(cmd)
+		if err != nil {
+			return withExitCode(exitConfigError, err)
+		}
+
 		cfg, tfPluginClient, err := loadConfigAndSetup(cmd)
 		if err != nil {
 			return err
 		}
-		err = spawner.Spawn(context.Background(), cfg, tfPluginClient)
-		if err != nil {
-			log.Fatal().Err(err).Send()
+		startedAt := time.Now()
+		result, err := spawner.Spawn(cmd.Context(), cfg, tfPluginClient, spawner.SpawnOptions{Exclude: quarantinedNodes(cmd)})
+		run, store := recordSpawn(cmd, cfg, result, startedAt, err)
+		if telemetryErr := influx.New(cfg.Influx).WriteSpawn(context.WithoutCancel(cmd.Context()), result); telemetryErr != nil {
+			log.Warn().Err(telemetryErr).Msg("failed to write deployment telemetry")
 		}
 
-		return nil
+		if printErr := printSpawnResult(os.Stdout, result, output); printErr != nil {
+			log.Error().Err(printErr).Msg("failed to print spawn result")
+		}
+		if reportPath != "" {
+			if reportErr := report.WriteFile(reportPath, reportFormat, result); reportErr != nil {
+				log.Error().Err(reportErr).Msg("failed to write spawn report")
+			} else {
+				log.Info().Str("path", reportPath).Msg("spawn report written")
+			}
+		}
+
+		spawnErr := spawnError(cmd.Context(), result, err)
+		if succeeded, _ := result.Counts(); !wait.enabled || succeeded == 0 || cmd.Context().Err() != nil {
+			return spawnErr
+		}
+
+		waitErr := waitForBenchmarks(cmd, cfg, tfPluginClient, store, run, wait)
+		if spawnErr == nil {
+			return waitErr
+		}
+		if waitErr != nil {
+			log.Error().Err(waitErr).Send()
+		}
+		return spawnErr
 	},
 }
+
+func init() {
+	spawnCmd.Flags().StringP("output", "o", "table", "output format of the spawn summary: table or json")
+	spawnCmd.Flags().String("report", "", "path of a report file to write the spawn result to")
+	spawnCmd.Flags().String("report-format", "", "format of the report file: json or junit (default: guessed from the file extension)")
+	spawnCmd.Flags().Bool("wait", false, "wait for the benchmarks of the deployed VMs to complete")
+	spawnCmd.Flags().Duration("wait-timeout", 2*time.Hour, "maximum time to wait for the benchmarks to complete")
+	spawnCmd.Flags().Duration("poll-interval", time.Minute, "interval between checks of the benchmarks completion")
+	spawnCmd.Flags().Bool("destroy-when-done", false, "destroy every VM as soon as its benchmark completes, requires --wait")
+}
+
+// recordSpawn records the spawn run in the run history and returns it with the history,
+// failing to do so only logs a warning and returns a nil history
+func recordSpawn(cmd *cobra.Command, cfg spawner.Config, result spawner.SpawnResult, startedAt time.Time, err error) (history.Run, *history.Store) {
+	run := history.Run{
+		ID:         result.RunID,
+		Farms:      cfg.Farms,
+		Status:     history.CompletedStatus,
+		StartedAt:  startedAt,
+		FinishedAt: time.Now(),
+		Config:     history.NewConfigSnapshot(cfg),
+	}
+	run.SetSpawn(result)
+	if err != nil {
+		run.Status = history.FailedStatus
+		run.Error = err.Error()
+	}
+
+	store, storeErr := openHistory(cmd)
+	if storeErr != nil {
+		log.Warn().Err(storeErr).Msg("failed to open the run history")
+		return run, nil
+	}
+	if err := store.Save(run); err != nil {
+		log.Warn().Err(err).Msg("failed to save the run")
+	}
+	store.TrackOutcomes(cmd.Context(), result, cfg.Quarantine)
+
+	return run, store
+}
+
+// spawnError maps the outcome of a spawn run to an error carrying the matching exit code
+func spawnError(ctx context.Context, result spawner.SpawnResult, err error) error {
+	if ctx.Err() != nil {
+		return withExitCode(exitInterrupted, errInterrupted)
+	}
+
+	var lookupErr *multierror.Error
+	for _, farm := range result.Farms {
+		if farm.LookupFailed() {
+			lookupErr = multierror.Append(lookupErr, fmt.Errorf("failed to get nodes of farm %d: %s", farm.Farm, farm.Error))
+		}
+	}
+
+	succeeded, failed := result.Counts()
+	if succeeded+failed == 0 {
+		if lookupErr != nil {
+			return withExitCode(exitGridError, lookupErr)
+		}
+		return withExitCode(exitTotalFailure, err)
+	}
+
+	if failed == 0 && err == nil && lookupErr == nil {
+		return nil
+	}
+	if err == nil && failed != 0 {
+		err = fmt.Errorf("%d of %d deployments failed", failed, succeeded+failed)
+	}
+
+	// a farm whose nodes could not be looked up is at least a partial failure of the run
+	code := failureExitCode(succeeded+failed, failed)
+	if code == exitOK {
+		code = exitPartialFailure
+	}
+	if lookupErr != nil {
+		err = multierror.Append(lookupErr, err).ErrorOrNil()
+	}
+
+	return withExitCode(code, err)
+}
+
+// printSpawnResult writes the spawn result to w in the given format.
+func printSpawnResult(w io.Writer, result spawner.SpawnResult, format string) error {
+	if format == "json" {
+		return report.WriteJSON(w, result)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "Farm\tNode\tNetworkContract\tVMContract\tPlanetaryIP\tMyceliumIP\tAttempts\tDuration\tClass\tError")
+	for _, farm := range result.Farms {
+		if len(farm.Nodes) == 0 {
+			fmt.Fprintf(tw, "%d\t-\t-\t-\t-\t-\t-\t-\t%s\t%s\n", farm.Farm, farm.ErrorClass, farm.Error)
+		}
+		for _, node := range farm.Nodes {
+			fmt.Fprintf(
+				tw, "%d\t%d\t%d\t%d\t%s\t%s\t%d\t%s\t%s\t%s\n",
+				farm.Farm, node.Node, node.NetworkContractID, node.VMContractID, node.PlanetaryIP,
+				node.MyceliumIP, node.Attempts, node.Duration.Round(time.Second), node.ErrorClass, node.Error,
+			)
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	succeeded, failed := result.Counts()
+	_, err := fmt.Fprintf(w, "\nrun %s: %d succeeded, %d failed in %s\n", result.RunID, succeeded, failed, result.Duration.Round(time.Second))
+	return err
+}
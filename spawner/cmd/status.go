@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	spawner "github.com/threefoldtech/guardians_healthchecker/spawner/pkg/spawner"
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "show the state of the workloads of the benchmark deployments and their zos errors",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return withExitCode(exitConfigError, fmt.Errorf("error in output format: %w", err))
+		}
+		if output != "table" && output != "json" {
+			return withExitCode(exitConfigError, fmt.Errorf("unsupported output format '%s', should be table or json", output))
+		}
+
+		cfg, tfPluginClient, err := loadConfigAndSetup(cmd)
+		if err != nil {
+			return err
+		}
+		statuses, err := spawner.Status(cmd.Context(), cfg, tfPluginClient)
+		if cmd.Context().Err() != nil {
+			return withExitCode(exitInterrupted, errInterrupted)
+		}
+
+		if printErr := printStatuses(os.Stdout, statuses, output); printErr != nil {
+			log.Error().Err(printErr).Msg("failed to print deployments status")
+		}
+
+		return withExitCode(exitGridError, err)
+	},
+}
+
+func init() {
+	statusCmd.Flags().StringP("output", "o", "table", "output format of the status: table or json")
+}
+
+// printStatuses writes the state of every workload of the deployments to w in the given format.
+func printStatuses(w io.Writer, statuses []spawner.DeploymentStatus, format string) error {
+	if format == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(statuses)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "Farm\tNode\tContract\tDeployment\tWorkload\tType\tState\tError")
+	for _, status := range statuses {
+		if status.Error != "" {
+			fmt.Fprintf(tw, "%d\t%d\t%d\t%s\t-\t-\t%s\t%s\n", status.Farm, status.Node, status.Contract, status.Name, status.ErrorClass, status.Error)
+			continue
+		}
+		for _, wl := range status.Workloads {
+			fmt.Fprintf(tw, "%d\t%d\t%d\t%s\t%s\t%s\t%s\t%s\n", status.Farm, status.Node, status.Contract, status.Name, wl.Name, wl.Type, wl.State, wl.Error)
+		}
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/threefoldtech/guardians_healthchecker/spawner/internal/history"
+	"github.com/threefoldtech/guardians_healthchecker/spawner/internal/influx"
+	"github.com/threefoldtech/guardians_healthchecker/spawner/internal/results"
+	spawner "github.com/threefoldtech/guardians_healthchecker/spawner/pkg/spawner"
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-client/deployer"
+)
+
+// spawnWait holds how spawn waits for the benchmarks to complete
+type spawnWait struct {
+	enabled         bool
+	timeout         time.Duration
+	interval        time.Duration
+	destroyWhenDone bool
+}
+
+// waitOptions reads the wait flags of the spawn command
+func waitOptions(cmd *cobra.Command) (spawnWait, error) {
+	var (
+		opts spawnWait
+		err  error
+	)
+
+	if opts.enabled, err = cmd.Flags().GetBool("wait"); err != nil {
+		return spawnWait{}, fmt.Errorf("error in wait: %w", err)
+	}
+	if opts.timeout, err = cmd.Flags().GetDuration("wait-timeout"); err != nil {
+		return spawnWait{}, fmt.Errorf("error in wait timeout: %w", err)
+	}
+	if opts.interval, err = cmd.Flags().GetDuration("poll-interval"); err != nil {
+		return spawnWait{}, fmt.Errorf("error in poll interval: %w", err)
+	}
+	if opts.destroyWhenDone, err = cmd.Flags().GetBool("destroy-when-done"); err != nil {
+		return spawnWait{}, fmt.Errorf("error in destroy-when-done: %w", err)
+	}
+
+	if opts.timeout <= 0 {
+		return spawnWait{}, fmt.Errorf("invalid wait timeout: %s, must be positive", opts.timeout)
+	}
+	if opts.interval <= 0 {
+		return spawnWait{}, fmt.Errorf("invalid poll interval: %s, must be positive", opts.interval)
+	}
+	if opts.destroyWhenDone && !opts.enabled {
+		return spawnWait{}, errors.New("--destroy-when-done requires --wait")
+	}
+
+	return opts, nil
+}
+
+// waitForBenchmarks waits for the deployed VMs of the run to report the score of every benchmark category, destroying
+// the recorded contracts of every node as soon as its benchmark completes if asked to, and records the destroyed VMs in the history
+func waitForBenchmarks(cmd *cobra.Command, cfg spawner.Config, tfPluginClient deployer.TFPluginClient, store *history.Store, run history.Run, opts spawnWait) error {
+	farms := make(map[uint32]uint64)
+	var nodes []uint32
+	for _, farm := range run.Spawn.Farms {
+		for _, node := range farm.Nodes {
+			if node.Succeeded() {
+				farms[node.Node] = farm.Farm
+				nodes = append(nodes, node.Node)
+			}
+		}
+	}
+
+	client := influx.New(cfg.Influx)
+	check := func(ctx context.Context, since time.Time) (map[uint32]bool, error) {
+		scores, err := client.QueryScores(ctx, since, time.Now())
+		return results.Completed(scores), err
+	}
+
+	var destroyed spawner.DestroyResult
+	done := func(completed []uint32) {
+		if !opts.destroyWhenDone {
+			return
+		}
+
+		destroyCfg := cfg
+		destroyCfg.Farms = nil
+		for _, node := range completed {
+			destroyCfg.Farms = append(destroyCfg.Farms, farms[node])
+		}
+		slices.Sort(destroyCfg.Farms)
+		destroyCfg.Farms = slices.Compact(destroyCfg.Farms)
+
+		destroyOpts := run.DestroyOptions()
+		destroyOpts.Nodes = completed
+		result, err := spawner.Destroy(cmd.Context(), destroyCfg, tfPluginClient, destroyOpts)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to destroy the VMs of the completed benchmarks")
+		}
+		destroyed.Farms = append(destroyed.Farms, result.Farms...)
+	}
+
+	log.Info().Str("Run", run.ID).Msgf("waiting up to %s for %d benchmarks to complete", opts.timeout, len(nodes))
+	ctx, cancel := context.WithTimeout(cmd.Context(), opts.timeout)
+	defer cancel()
+
+	pending, err := results.WaitCompleted(ctx, check, nodes, run.StartedAt, opts.interval, done)
+
+	if store != nil && opts.destroyWhenDone {
+		saveErr := store.Update(run.ID, func(run *history.Run) error {
+			run.Destroy = &destroyed
+			run.FinishedAt = time.Now()
+			return nil
+		})
+		if saveErr != nil {
+			log.Warn().Err(saveErr).Msg("failed to save the run")
+		}
+	}
+
+	if cmd.Context().Err() != nil {
+		return withExitCode(exitInterrupted, errInterrupted)
+	}
+	if err != nil {
+		return withExitCode(failureExitCode(len(nodes), len(pending)), fmt.Errorf("%d of %d benchmarks did not complete within %s: %v", len(pending), len(nodes), opts.timeout, pending))
+	}
+
+	log.Info().Str("Run", run.ID).Msg("all benchmarks completed")
+	return nil
+}
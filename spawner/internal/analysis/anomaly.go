@@ -0,0 +1,146 @@
+package analysis
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/threefoldtech/guardians_healthchecker/spawner/internal/results"
+)
+
+const (
+	// minGroupSize is the smallest number of nodes sharing a farm and hardware profile compared with each other,
+	// the interquartile range fences of smaller groups can never flag a single outlier
+	minGroupSize = 4
+	// iqrFactor sets the fences of the outliers as a multiple of the interquartile range beyond the quartiles
+	iqrFactor = 1.5
+	// minDeviation is the smallest relative distance to the median flagged, so groups scoring almost
+	// the same do not flag tiny differences
+	minDeviation = 0.25
+)
+
+// Anomaly is a benchmark score of a node far off the scores of the nodes with the same hardware on its farm
+type Anomaly struct {
+	Farm     uint64  `json:"farm"`
+	Node     uint32  `json:"node"`
+	Profile  string  `json:"profile"`
+	Category string  `json:"category"`
+	Score    float64 `json:"score"`
+	Median   float64 `json:"median"`
+	// Below is set if the score is below the median
+	Below       bool   `json:"below"`
+	Explanation string `json:"explanation"`
+}
+
+// groupKey identifies the nodes of a farm with the same hardware profile
+type groupKey struct {
+	farm    uint64
+	profile string
+}
+
+// FindAnomalies groups the nodes of a run by farm and hardware profile and flags the scores outside
+// the interquartile range fences of their group, ordered by farm, node and category
+func FindAnomalies(scores []results.NodeScores, profiles map[uint32]string) []Anomaly {
+	groups := make(map[groupKey][]results.NodeScores)
+	for _, node := range scores {
+		profile, ok := profiles[node.Node]
+		if !ok || !node.Reported() {
+			continue
+		}
+		key := groupKey{farm: node.Farm, profile: profile}
+		groups[key] = append(groups[key], node)
+	}
+
+	var anomalies []Anomaly
+	for key, nodes := range groups {
+		if len(nodes) < minGroupSize {
+			continue
+		}
+
+		for _, category := range categories(nodes) {
+			var values []float64
+			for _, node := range nodes {
+				if score, ok := node.Scores[category]; ok {
+					values = append(values, score)
+				}
+			}
+			if len(values) < minGroupSize {
+				continue
+			}
+
+			slices.Sort(values)
+			mid := median(values)
+			q1, q3 := quantile(values, 0.25), quantile(values, 0.75)
+			lower, upper := q1-iqrFactor*(q3-q1), q3+iqrFactor*(q3-q1)
+
+			for _, node := range nodes {
+				score, ok := node.Scores[category]
+				if !ok || (score >= lower && score <= upper) || !deviates(score, mid) {
+					continue
+				}
+
+				anomalies = append(anomalies, Anomaly{
+					Farm:        key.farm,
+					Node:        node.Node,
+					Profile:     key.profile,
+					Category:    category,
+					Score:       score,
+					Median:      mid,
+					Below:       score < mid,
+					Explanation: explain(node.Node, category, score, mid),
+				})
+			}
+		}
+	}
+
+	sort.Slice(anomalies, func(i, j int) bool {
+		if anomalies[i].Farm != anomalies[j].Farm {
+			return anomalies[i].Farm < anomalies[j].Farm
+		}
+		if anomalies[i].Node != anomalies[j].Node {
+			return anomalies[i].Node < anomalies[j].Node
+		}
+		return anomalies[i].Category < anomalies[j].Category
+	})
+
+	return anomalies
+}
+
+// categories returns the sorted benchmark categories scored by any of the nodes
+func categories(nodes []results.NodeScores) []string {
+	var categories []string
+	for _, node := range nodes {
+		for category := range node.Scores {
+			if !slices.Contains(categories, category) {
+				categories = append(categories, category)
+			}
+		}
+	}
+	slices.Sort(categories)
+
+	return categories
+}
+
+// deviates reports whether the score is far enough from the median to be worth flagging
+func deviates(score, median float64) bool {
+	if median == 0 {
+		return score != 0
+	}
+	diff := score - median
+	if diff < 0 {
+		diff = -diff
+	}
+	return diff/median >= minDeviation
+}
+
+// explain describes how far the score of a node is from the median of its group
+func explain(node uint32, category string, score, median float64) string {
+	switch {
+	case score <= 0 || median <= 0:
+		return fmt.Sprintf("node %d %s scored %.2f, farm median is %.2f", node, category, score, median)
+	case score < median:
+		return fmt.Sprintf("node %d %s %.1fx below farm median", node, category, median/score)
+	default:
+		return fmt.Sprintf("node %d %s %.1fx above farm median", node, category, score/median)
+	}
+}
@@ -0,0 +1,61 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/threefoldtech/guardians_healthchecker/spawner/internal/results"
+	"gotest.tools/assert"
+)
+
+func TestFindAnomalies(t *testing.T) {
+	profiles := map[uint32]string{
+		11: "xeon/24c/128G", 12: "xeon/24c/128G", 13: "xeon/24c/128G", 14: "xeon/24c/128G", 15: "xeon/24c/128G",
+		21: "epyc/64c/256G", 22: "epyc/64c/256G",
+	}
+	scores := []results.NodeScores{
+		{Farm: 1, Node: 11, Scores: map[string]float64{"cpu": 1000, "disk": 400}},
+		{Farm: 1, Node: 12, Scores: map[string]float64{"cpu": 1020, "disk": 410}},
+		{Farm: 1, Node: 13, Scores: map[string]float64{"cpu": 990, "disk": 100}},
+		{Farm: 1, Node: 14, Scores: map[string]float64{"cpu": 1010, "disk": 390}},
+		{Farm: 1, Node: 15, Scores: map[string]float64{"cpu": 1005, "disk": 405}},
+		// too few nodes share this profile to compare them
+		{Farm: 1, Node: 21, Scores: map[string]float64{"cpu": 3000}},
+		{Farm: 1, Node: 22, Scores: map[string]float64{"cpu": 100}},
+		// no profile
+		{Farm: 1, Node: 31, Scores: map[string]float64{"cpu": 1}},
+	}
+
+	anomalies := FindAnomalies(scores, profiles)
+	assert.Equal(t, len(anomalies), 1)
+	assert.Equal(t, anomalies[0].Node, uint32(13))
+	assert.Equal(t, anomalies[0].Category, "disk")
+	assert.Equal(t, anomalies[0].Median, 400.0)
+	assert.Assert(t, anomalies[0].Below)
+	assert.Equal(t, anomalies[0].Explanation, "node 13 disk 4.0x below farm median")
+}
+
+func TestFindAnomaliesSmallGroups(t *testing.T) {
+	profiles := map[uint32]string{11: "xeon/24c/128G", 12: "xeon/24c/128G", 13: "xeon/24c/128G", 14: "xeon/24c/128G"}
+	scores := []results.NodeScores{
+		{Farm: 1, Node: 11, Scores: map[string]float64{"cpu": 1}},
+		{Farm: 1, Node: 12, Scores: map[string]float64{"cpu": 10}},
+		{Farm: 1, Node: 13, Scores: map[string]float64{"cpu": 10}},
+		{Farm: 1, Node: 14, Scores: map[string]float64{"cpu": 10}},
+	}
+
+	anomalies := FindAnomalies(scores, profiles)
+	assert.Equal(t, len(anomalies), 1)
+	assert.Equal(t, anomalies[0].Node, uint32(11))
+	assert.Equal(t, anomalies[0].Median, 10.0)
+
+	// too few nodes share the profile once node 14 is gone
+	assert.Equal(t, len(FindAnomalies(scores[:3], profiles)), 0)
+}
+
+func TestQuantile(t *testing.T) {
+	values := []float64{1, 2, 3, 4, 5}
+	assert.Equal(t, quantile(values, 0.25), 2.0)
+	assert.Equal(t, quantile(values, 0.5), 3.0)
+	assert.Equal(t, quantile(values, 1), 5.0)
+	assert.Equal(t, quantile([]float64{1, 2}, 0.75), 1.75)
+}
@@ -0,0 +1,184 @@
+package analysis
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+
+	"github.com/threefoldtech/guardians_healthchecker/spawner/internal/results"
+)
+
+// maxSamples is the number of most recent scores kept per node and category
+const maxSamples = 10
+
+// Sources of the expected score of a node
+const (
+	NodeSource    = "node"
+	ProfileSource = "profile"
+)
+
+// Baseline holds the recent benchmark scores of every node, a node is expected to score around the
+// median of its own scores, or around the median of the nodes with the same hardware profile if it has none
+type Baseline struct {
+	Runs  []string                `json:"runs"`
+	Nodes map[uint32]NodeBaseline `json:"nodes"`
+}
+
+// NodeBaseline holds the recent scores of a node by category
+type NodeBaseline struct {
+	Profile string               `json:"profile,omitempty"`
+	Samples map[string][]float64 `json:"samples"`
+}
+
+// Regression is a benchmark score of a node which dropped beyond the threshold
+type Regression struct {
+	Farm     uint64  `json:"farm"`
+	Node     uint32  `json:"node"`
+	Category string  `json:"category"`
+	Score    float64 `json:"score"`
+	Expected float64 `json:"expected"`
+	// Drop is the relative drop of the score, 0.25 is 25% below the expected score
+	Drop   float64 `json:"drop"`
+	Source string  `json:"source"`
+}
+
+// LoadBaseline reads the baseline file, a missing file is an empty baseline
+func LoadBaseline(path string) (*Baseline, error) {
+	baseline := &Baseline{Nodes: make(map[uint32]NodeBaseline)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return baseline, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline '%s': %w", path, err)
+	}
+
+	if err := json.Unmarshal(data, baseline); err != nil {
+		return nil, fmt.Errorf("failed to decode baseline '%s': %w", path, err)
+	}
+	if baseline.Nodes == nil {
+		baseline.Nodes = make(map[uint32]NodeBaseline)
+	}
+
+	return baseline, nil
+}
+
+// Save writes the baseline file
+func (b *Baseline) Save(path string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode baseline: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write baseline '%s': %w", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write baseline '%s': %w", path, err)
+	}
+
+	return nil
+}
+
+// Contains reports whether the scores of the run are recorded in the baseline
+func (b *Baseline) Contains(runID string) bool {
+	return slices.Contains(b.Runs, runID)
+}
+
+// Add records the scores of a run in the baseline, it reports false if the run is already recorded
+func (b *Baseline) Add(runID string, scores []results.NodeScores, profiles map[uint32]string) bool {
+	if b.Contains(runID) {
+		return false
+	}
+	b.Runs = append(b.Runs, runID)
+
+	for _, node := range scores {
+		if !node.Reported() {
+			continue
+		}
+
+		baseline, ok := b.Nodes[node.Node]
+		if !ok {
+			baseline.Samples = make(map[string][]float64)
+		}
+		if profile, ok := profiles[node.Node]; ok {
+			baseline.Profile = profile
+		}
+
+		for category, score := range node.Scores {
+			samples := append(baseline.Samples[category], score)
+			if len(samples) > maxSamples {
+				samples = samples[len(samples)-maxSamples:]
+			}
+			baseline.Samples[category] = samples
+		}
+		b.Nodes[node.Node] = baseline
+	}
+
+	return true
+}
+
+// Expected returns the expected score of a node in a category and where it comes from
+func (b *Baseline) Expected(node uint32, category, profile string) (float64, string, bool) {
+	if samples := b.Nodes[node].Samples[category]; len(samples) != 0 {
+		return median(samples), NodeSource, true
+	}
+	if profile == "" {
+		return 0, "", false
+	}
+
+	var medians []float64
+	for _, baseline := range b.Nodes {
+		if baseline.Profile == profile && len(baseline.Samples[category]) != 0 {
+			medians = append(medians, median(baseline.Samples[category]))
+		}
+	}
+	if len(medians) == 0 {
+		return 0, "", false
+	}
+
+	return median(medians), ProfileSource, true
+}
+
+// Compare flags the scores dropping more than threshold, a fraction of the expected score, ordered by farm, node and category
+func (b *Baseline) Compare(scores []results.NodeScores, profiles map[uint32]string, threshold float64) []Regression {
+	var regressions []Regression
+	for _, node := range scores {
+		for category, score := range node.Scores {
+			expected, source, ok := b.Expected(node.Node, category, profiles[node.Node])
+			if !ok || expected <= 0 {
+				continue
+			}
+
+			drop := (expected - score) / expected
+			if drop > threshold {
+				regressions = append(regressions, Regression{
+					Farm:     node.Farm,
+					Node:     node.Node,
+					Category: category,
+					Score:    score,
+					Expected: expected,
+					Drop:     drop,
+					Source:   source,
+				})
+			}
+		}
+	}
+
+	sort.Slice(regressions, func(i, j int) bool {
+		if regressions[i].Farm != regressions[j].Farm {
+			return regressions[i].Farm < regressions[j].Farm
+		}
+		if regressions[i].Node != regressions[j].Node {
+			return regressions[i].Node < regressions[j].Node
+		}
+		return regressions[i].Category < regressions[j].Category
+	})
+
+	return regressions
+}
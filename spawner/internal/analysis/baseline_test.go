@@ -0,0 +1,63 @@
+package analysis
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/threefoldtech/guardians_healthchecker/spawner/internal/results"
+	"gotest.tools/assert"
+)
+
+func TestBaseline(t *testing.T) {
+	baseline, err := LoadBaseline(filepath.Join(t.TempDir(), "missing.json"))
+	assert.NilError(t, err)
+
+	profiles := map[uint32]string{11: "xeon/24c/128G", 12: "xeon/24c/128G", 13: "xeon/24c/128G"}
+	for i, cpu := range []float64{1000, 1100, 900} {
+		added := baseline.Add(string(rune('a'+i)), []results.NodeScores{
+			{Farm: 1, Node: 11, Scores: map[string]float64{"cpu": cpu, "disk": 500}},
+			{Farm: 1, Node: 12, Scores: map[string]float64{"cpu": cpu * 2}},
+		}, profiles)
+		assert.Assert(t, added)
+	}
+	assert.Assert(t, !baseline.Add("a", nil, profiles))
+	assert.Assert(t, baseline.Contains("c"))
+	assert.Assert(t, !baseline.Contains("d"))
+
+	t.Run("expected scores", func(t *testing.T) {
+		expected, source, ok := baseline.Expected(11, "cpu", profiles[11])
+		assert.Assert(t, ok)
+		assert.Equal(t, expected, 1000.0)
+		assert.Equal(t, source, NodeSource)
+
+		expected, source, ok = baseline.Expected(13, "cpu", profiles[13])
+		assert.Assert(t, ok)
+		assert.Equal(t, expected, 1500.0)
+		assert.Equal(t, source, ProfileSource)
+
+		_, _, ok = baseline.Expected(14, "cpu", "")
+		assert.Assert(t, !ok)
+	})
+	t.Run("regressions", func(t *testing.T) {
+		regressions := baseline.Compare([]results.NodeScores{
+			{Farm: 1, Node: 11, Scores: map[string]float64{"cpu": 700, "disk": 480}},
+			{Farm: 1, Node: 12, Scores: map[string]float64{"cpu": 1900}},
+			{Farm: 1, Node: 13, Scores: map[string]float64{"cpu": 1000}},
+		}, profiles, 0.2)
+
+		assert.Equal(t, len(regressions), 2)
+		assert.Equal(t, regressions[0].Node, uint32(11))
+		assert.Equal(t, regressions[0].Category, "cpu")
+		assert.Equal(t, regressions[0].Drop, 0.3)
+		assert.Equal(t, regressions[1].Node, uint32(13))
+		assert.Equal(t, regressions[1].Source, ProfileSource)
+	})
+	t.Run("save and load", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "baseline.json")
+		assert.NilError(t, baseline.Save(path))
+
+		loaded, err := LoadBaseline(path)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, loaded, baseline)
+	})
+}
@@ -0,0 +1,53 @@
+package analysis
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/types"
+)
+
+// gb is the number of bytes in a gigabyte
+const gb = 1024 * 1024 * 1024
+
+// Profile returns the hardware profile of a node, nodes with the same CPU model, number of cores
+// and memory are expected to score similarly
+func Profile(node types.Node) string {
+	cpu := "unknown"
+	if len(node.Dmi.Processor) != 0 && strings.TrimSpace(node.Dmi.Processor[0].Version) != "" {
+		cpu = strings.Join(strings.Fields(node.Dmi.Processor[0].Version), " ")
+	}
+
+	return fmt.Sprintf("%s/%dc/%dG", cpu, node.TotalResources.CRU, (uint64(node.TotalResources.MRU)+gb/2)/gb)
+}
+
+// median returns the median of the values, zero if there are none
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// quantile returns the q quantile of the sorted values using linear interpolation
+func quantile(sorted []float64, q float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	pos := q * float64(len(sorted)-1)
+	lower := int(pos)
+	if lower+1 >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[lower] + (pos-float64(lower))*(sorted[lower+1]-sorted[lower])
+}
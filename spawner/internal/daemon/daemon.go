@@ -0,0 +1,170 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/robfig/cron/v3"
+	"github.com/rs/zerolog/log"
+	"github.com/threefoldtech/guardians_healthchecker/spawner/internal/history"
+	"github.com/threefoldtech/guardians_healthchecker/spawner/internal/influx"
+	"github.com/threefoldtech/guardians_healthchecker/spawner/internal/metrics"
+	spawner "github.com/threefoldtech/guardians_healthchecker/spawner/pkg/spawner"
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-client/deployer"
+)
+
+// cleanupTimeout bounds the destroy of a cycle once the daemon is stopping
+const cleanupTimeout = 10 * time.Minute
+
+// Daemon runs the configured campaigns on their cron schedule
+type Daemon struct {
+	cfg            spawner.Config
+	tfPluginClient deployer.TFPluginClient
+	history        *history.Store
+	metrics        *metrics.Metrics
+	influx         *influx.Client
+
+	// cycle is held while a cycle runs, campaigns deploy to the same projects so cycles never overlap
+	cycle sync.Mutex
+}
+
+// New returns a daemon running the campaigns of cfg, recording them in store and in m
+func New(cfg spawner.Config, tfPluginClient deployer.TFPluginClient, store *history.Store, m *metrics.Metrics) *Daemon {
+	return &Daemon{
+		cfg:            cfg,
+		tfPluginClient: tfPluginClient,
+		history:        store,
+		metrics:        m,
+		influx:         influx.New(cfg.Influx),
+	}
+}
+
+// Run schedules the campaigns and blocks until ctx is done, the running cycle is then
+// finished if it is destroying its VMs or rolled back otherwise
+func (d *Daemon) Run(ctx context.Context) error {
+	if len(d.cfg.Campaigns) == 0 {
+		return errors.New("no campaigns are configured")
+	}
+
+	scheduler := cron.New()
+	for _, campaign := range d.cfg.Campaigns {
+		campaign := campaign
+		_, err := scheduler.AddFunc(campaign.Schedule, func() {
+			d.runCycle(ctx, campaign)
+		})
+		if err != nil {
+			return fmt.Errorf("invalid schedule of campaign %s: %w", campaign.Name, err)
+		}
+		log.Info().Str("Campaign", campaign.Name).Str("Schedule", campaign.Schedule).Msg("campaign scheduled")
+	}
+
+	scheduler.Start()
+	<-ctx.Done()
+
+	log.Info().Msg("stopping daemon")
+	<-scheduler.Stop().Done()
+
+	return nil
+}
+
+// runCycle spawns the VMs of a campaign, waits for the benchmark duration then destroys them
+func (d *Daemon) runCycle(ctx context.Context, campaign spawner.Campaign) {
+	if !d.cycle.TryLock() {
+		log.Warn().Str("Campaign", campaign.Name).Msg("skipping cycle, another cycle is still running")
+		return
+	}
+	defer d.cycle.Unlock()
+
+	if ctx.Err() != nil {
+		return
+	}
+
+	d.reap(ctx)
+
+	cfg := d.cfg
+	if len(campaign.Farms) != 0 {
+		cfg.Farms = campaign.Farms
+	}
+
+	run := history.Run{
+		ID:        spawner.NewRunID(),
+		Campaign:  campaign.Name,
+		Farms:     cfg.Farms,
+		Status:    history.RunningStatus,
+		StartedAt: time.Now(),
+		Config:    history.NewConfigSnapshot(cfg),
+	}
+	d.save(run)
+	log.Info().Str("Campaign", campaign.Name).Str("Run", run.ID).Msg("starting cycle")
+
+	result, err := spawner.Spawn(ctx, cfg, d.tfPluginClient, spawner.SpawnOptions{RunID: run.ID, Exclude: d.history.ExcludedNodes()})
+	run.SetSpawn(result)
+	d.metrics.ObserveSpawn(result)
+	if err := d.influx.WriteSpawn(context.WithoutCancel(ctx), result); err != nil {
+		log.Warn().Err(err).Str("Run", run.ID).Msg("failed to write deployment telemetry")
+	}
+	if err != nil {
+		run.Error = err.Error()
+	}
+	d.save(run)
+	d.history.TrackOutcomes(ctx, result, cfg.Quarantine)
+
+	succeeded, _ := result.Counts()
+	if ctx.Err() == nil && succeeded != 0 {
+		log.Info().Str("Run", run.ID).Msgf("waiting %s for the benchmarks to finish", campaign.Duration)
+		select {
+		case <-ctx.Done():
+		case <-time.After(campaign.Duration):
+		}
+	}
+
+	// the VMs are destroyed even if the daemon is stopping, an interrupted cycle is rolled back
+	rolledBack := ctx.Err() != nil
+	destroyCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cleanupTimeout)
+	defer cancel()
+
+	destroyResult, destroyErr := spawner.Destroy(destroyCtx, cfg, d.tfPluginClient, run.DestroyOptions())
+	run.Destroy = &destroyResult
+	run.FinishedAt = time.Now()
+	d.metrics.ObserveDestroy(destroyResult)
+
+	switch {
+	case rolledBack:
+		run.Status = history.RolledBackStatus
+	case err != nil || destroyErr != nil:
+		run.Status = history.FailedStatus
+	default:
+		run.Status = history.CompletedStatus
+	}
+	if destroyErr != nil {
+		run.Error = multierror.Append(err, destroyErr).Error()
+	}
+	d.save(run)
+
+	log.Info().Str("Campaign", campaign.Name).Str("Run", run.ID).Str("Status", string(run.Status)).Msgf("cycle took %s", run.FinishedAt.Sub(run.StartedAt))
+}
+
+// reap destroys the deployments past their expiry, such as the VMs of cycles which failed to be destroyed
+func (d *Daemon) reap(ctx context.Context) {
+	expired, result, err := spawner.Reap(ctx, d.tfPluginClient)
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to reap expired deployments")
+	}
+	if len(expired) == 0 {
+		return
+	}
+
+	d.metrics.ObserveDestroy(result)
+	log.Info().Msgf("reaped %d expired contracts", len(expired))
+}
+
+// save records the run in the history, failing to do so does not stop the cycle
+func (d *Daemon) save(run history.Run) {
+	if err := d.history.Save(run); err != nil {
+		log.Error().Err(err).Str("Run", run.ID).Msg("failed to save run")
+	}
+}
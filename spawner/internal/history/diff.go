@@ -0,0 +1,152 @@
+package history
+
+import (
+	"slices"
+	"sort"
+
+	spawner "github.com/threefoldtech/guardians_healthchecker/spawner/pkg/spawner"
+)
+
+// NodeState is the health of a node in a run
+type NodeState string
+
+// States of a node in a run
+const (
+	// HealthyState is a node whose VM deployed and, if the scores of the run were collected, reported scores
+	HealthyState NodeState = "healthy"
+	// SilentState is a node whose VM deployed but never reported scores
+	SilentState NodeState = "silent"
+	// FailingState is a node whose VM failed to deploy
+	FailingState NodeState = "failing"
+)
+
+// NodeChange is a node whose state differs between two runs
+type NodeChange struct {
+	Farm uint64    `json:"farm"`
+	Node uint32    `json:"node"`
+	From NodeState `json:"from"`
+	To   NodeState `json:"to"`
+}
+
+// Regressed reports whether the node was healthy in the earlier run and is not anymore
+func (c NodeChange) Regressed() bool {
+	return c.From == HealthyState
+}
+
+// IneligibleNode is a node eligible for deployment in the earlier run which was not in the later one
+type IneligibleNode struct {
+	Farm uint64 `json:"farm"`
+	Node uint32 `json:"node"`
+}
+
+// ScoreDelta is the change of the score of a node in a category between two runs
+type ScoreDelta struct {
+	Farm     uint64  `json:"farm"`
+	Node     uint32  `json:"node"`
+	Category string  `json:"category"`
+	From     float64 `json:"from"`
+	To       float64 `json:"to"`
+	// Change is relative to the earlier score, -0.25 is 25% below it
+	Change float64 `json:"change"`
+}
+
+// RunDiff holds the nodes whose state, eligibility or scores changed between two runs
+type RunDiff struct {
+	From       string           `json:"from"`
+	To         string           `json:"to"`
+	Changes    []NodeChange     `json:"changes"`
+	Ineligible []IneligibleNode `json:"ineligible"`
+	Scores     []ScoreDelta     `json:"scores"`
+}
+
+// NodeStates returns the state of every node of the run
+func (r Run) NodeStates() map[uint32]NodeState {
+	states := make(map[uint32]NodeState)
+	if r.Spawn == nil {
+		return states
+	}
+
+	for _, farm := range r.Spawn.Farms {
+		for _, node := range farm.Nodes {
+			switch {
+			case !node.Succeeded():
+				states[node.Node] = FailingState
+			case r.Scores != nil && len(r.Scores[node.Node]) == 0:
+				states[node.Node] = SilentState
+			default:
+				states[node.Node] = HealthyState
+			}
+		}
+	}
+
+	return states
+}
+
+// Diff returns the nodes whose state or scores changed from the first run to the second one, and the nodes
+// which dropped out of eligibility on the farms of both runs, ordered by farm and node
+func Diff(from, to Run) RunDiff {
+	diff := RunDiff{From: from.ID, To: to.ID}
+	if from.Spawn == nil || to.Spawn == nil {
+		return diff
+	}
+
+	before, after := from.NodeStates(), to.NodeStates()
+	for _, farm := range to.Spawn.Farms {
+		for _, node := range farm.Nodes {
+			previous, ok := before[node.Node]
+			if ok && previous != after[node.Node] {
+				diff.Changes = append(diff.Changes, NodeChange{Farm: farm.Farm, Node: node.Node, From: previous, To: after[node.Node]})
+			}
+
+			for category, score := range to.Scores[node.Node] {
+				previous, ok := from.Scores[node.Node][category]
+				if !ok || previous == 0 {
+					continue
+				}
+				diff.Scores = append(diff.Scores, ScoreDelta{
+					Farm:     farm.Farm,
+					Node:     node.Node,
+					Category: category,
+					From:     previous,
+					To:       score,
+					Change:   (score - previous) / previous,
+				})
+			}
+		}
+
+		// eligibility is only comparable if both runs looked the farm up successfully
+		idx := slices.IndexFunc(from.Spawn.Farms, func(f spawner.FarmResult) bool { return f.Farm == farm.Farm })
+		if idx == -1 || farm.LookupFailed() || from.Spawn.Farms[idx].LookupFailed() {
+			continue
+		}
+		for _, node := range from.Spawn.Farms[idx].Eligible {
+			if !slices.Contains(farm.Eligible, node) {
+				diff.Ineligible = append(diff.Ineligible, IneligibleNode{Farm: farm.Farm, Node: node})
+			}
+		}
+	}
+
+	sort.Slice(diff.Changes, func(i, j int) bool {
+		if diff.Changes[i].Farm != diff.Changes[j].Farm {
+			return diff.Changes[i].Farm < diff.Changes[j].Farm
+		}
+		return diff.Changes[i].Node < diff.Changes[j].Node
+	})
+	sort.Slice(diff.Ineligible, func(i, j int) bool {
+		if diff.Ineligible[i].Farm != diff.Ineligible[j].Farm {
+			return diff.Ineligible[i].Farm < diff.Ineligible[j].Farm
+		}
+		return diff.Ineligible[i].Node < diff.Ineligible[j].Node
+	})
+	sort.Slice(diff.Scores, func(i, j int) bool {
+		if diff.Scores[i].Farm != diff.Scores[j].Farm {
+			return diff.Scores[i].Farm < diff.Scores[j].Farm
+		}
+		if diff.Scores[i].Node != diff.Scores[j].Node {
+			return diff.Scores[i].Node < diff.Scores[j].Node
+		}
+		return diff.Scores[i].Category < diff.Scores[j].Category
+	})
+
+	return diff
+}
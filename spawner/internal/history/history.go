@@ -0,0 +1,280 @@
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+
+	spawner "github.com/threefoldtech/guardians_healthchecker/spawner/pkg/spawner"
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/types"
+	bolt "go.etcd.io/bbolt"
+)
+
+// RunStatus is the state of a recorded run
+type RunStatus string
+
+// Statuses of a recorded run
+const (
+	RunningStatus    RunStatus = "running"
+	CompletedStatus  RunStatus = "completed"
+	FailedStatus     RunStatus = "failed"
+	RolledBackStatus RunStatus = "rolled_back"
+	DestroyedStatus  RunStatus = "destroyed"
+)
+
+// ErrRunNotFound is returned when a run is not in the history
+var ErrRunNotFound = errors.New("run not found")
+
+// Run is a spawn and destroy cycle recorded in the history
+type Run struct {
+	ID         string                 `json:"id"`
+	Campaign   string                 `json:"campaign,omitempty"`
+	Farms      []uint64               `json:"farms"`
+	Status     RunStatus              `json:"status"`
+	StartedAt  time.Time              `json:"started_at"`
+	FinishedAt time.Time              `json:"finished_at,omitempty"`
+	Config     *ConfigSnapshot        `json:"config,omitempty"`
+	Spawn      *spawner.SpawnResult   `json:"spawn,omitempty"`
+	Destroy    *spawner.DestroyResult `json:"destroy,omitempty"`
+	// Scores holds the benchmark scores of every node by category, recorded when the results of the run are collected
+	Scores map[uint32]map[string]float64 `json:"scores,omitempty"`
+	// Inventory holds the grid proxy records of the eligible nodes of every farm at the time the run spawned
+	Inventory []types.Node `json:"inventory,omitempty"`
+	Error     string       `json:"error,omitempty"`
+}
+
+// SetSpawn records the spawn result of the run along with the grid proxy records of its eligible nodes
+func (r *Run) SetSpawn(result spawner.SpawnResult) {
+	r.Spawn = &result
+	r.Inventory = nil
+	for _, farm := range result.Farms {
+		r.Inventory = append(r.Inventory, farm.Inventory...)
+	}
+}
+
+// NodeRecords returns the grid proxy records of the eligible nodes of the run at the time it spawned, keyed by node ID
+func (r Run) NodeRecords() map[uint32]types.Node {
+	records := make(map[uint32]types.Node, len(r.Inventory))
+	for _, node := range r.Inventory {
+		records[uint32(node.NodeID)] = node
+	}
+
+	return records
+}
+
+// DestroyOptions selects the contracts created by the run, the run ID is only used if none was recorded
+// as it can not match the deployments on unreachable nodes
+func (r Run) DestroyOptions() spawner.DestroyOptions {
+	if r.Spawn != nil {
+		if contracts := r.Spawn.Contracts(); len(contracts) != 0 {
+			return spawner.DestroyOptions{Contracts: contracts}
+		}
+	}
+	return spawner.DestroyOptions{RunID: r.ID}
+}
+
+// ConfigSnapshot is the configuration a run was started with, without the mnemonic and the tokens
+type ConfigSnapshot struct {
+	Farms              []uint64          `json:"farms"`
+	DeploymentStrategy float64           `json:"deployment_strategy"`
+	FailureStrategy    string            `json:"failure_strategy"`
+	GridEndpoints      spawner.Endpoints `json:"grid_endpoints"`
+	InfluxURL          string            `json:"influx_url,omitempty"`
+	InfluxBucket       string            `json:"influx_bucket,omitempty"`
+	TTL                time.Duration     `json:"ttl,omitempty"`
+	Profile            string            `json:"profile,omitempty"`
+}
+
+// NewConfigSnapshot returns the snapshot of the configuration to record with a run
+func NewConfigSnapshot(cfg spawner.Config) *ConfigSnapshot {
+	return &ConfigSnapshot{
+		Farms:              cfg.Farms,
+		DeploymentStrategy: cfg.DeploymentStrategy,
+		FailureStrategy:    cfg.FailureStrategy,
+		GridEndpoints:      cfg.GridEndpoints,
+		InfluxURL:          cfg.Influx.URL,
+		InfluxBucket:       cfg.Influx.Bucket,
+		TTL:                cfg.TTL,
+		Profile:            cfg.Profile,
+	}
+}
+
+// MergeSpawn merges the node results of a later spawn of the run, such as a redeploy of some of its nodes,
+// into the recorded spawn result
+func (r *Run) MergeSpawn(result spawner.SpawnResult) {
+	if r.Spawn == nil {
+		r.SetSpawn(result)
+		return
+	}
+
+	for _, farm := range result.Farms {
+		idx := slices.IndexFunc(r.Spawn.Farms, func(f spawner.FarmResult) bool { return f.Farm == farm.Farm })
+		if idx == -1 {
+			r.Spawn.Farms = append(r.Spawn.Farms, farm)
+			continue
+		}
+
+		recorded := &r.Spawn.Farms[idx]
+		for _, node := range farm.Nodes {
+			nodeIdx := slices.IndexFunc(recorded.Nodes, func(n spawner.NodeResult) bool { return n.Node == node.Node })
+			if nodeIdx == -1 {
+				recorded.Nodes = append(recorded.Nodes, node)
+			} else {
+				recorded.Nodes[nodeIdx] = node
+			}
+		}
+	}
+}
+
+// Store persists the runs in an embedded bbolt database, the database is only opened for the
+// duration of every operation so a running daemon does not lock out the other commands
+type Store struct {
+	path string
+}
+
+// runsBucket is the bucket holding the runs keyed by ID
+var runsBucket = []byte("runs")
+
+// openTimeout bounds the wait for the database lock held by another process
+const openTimeout = 10 * time.Second
+
+// NewStore returns a store of the runs in the database at path, the database and its directory are created if missing
+func NewStore(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create history directory '%s': %w", filepath.Dir(path), err)
+	}
+
+	s := &Store{path: path}
+	err := s.update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(runsBucket)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Save creates or replaces the run in the history
+func (s *Store) Save(run Run) error {
+	data, err := json.Marshal(run)
+	if err != nil {
+		return fmt.Errorf("failed to encode run %s: %w", run.ID, err)
+	}
+
+	err = s.update(func(tx *bolt.Tx) error {
+		return tx.Bucket(runsBucket).Put([]byte(run.ID), data)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write run %s: %w", run.ID, err)
+	}
+
+	return nil
+}
+
+// Get returns the run with the given ID
+func (s *Store) Get(id string) (Run, error) {
+	var run Run
+	err := s.view(func(tx *bolt.Tx) error {
+		data := tx.Bucket(runsBucket).Get([]byte(id))
+		if data == nil {
+			return fmt.Errorf("%w: %s", ErrRunNotFound, id)
+		}
+		if err := json.Unmarshal(data, &run); err != nil {
+			return fmt.Errorf("failed to decode run %s: %w", id, err)
+		}
+		return nil
+	})
+
+	return run, err
+}
+
+// List returns all the runs in the history ordered by start time
+func (s *Store) List() ([]Run, error) {
+	var runs []Run
+	err := s.view(func(tx *bolt.Tx) error {
+		return tx.Bucket(runsBucket).ForEach(func(id, data []byte) error {
+			var run Run
+			if err := json.Unmarshal(data, &run); err != nil {
+				return fmt.Errorf("failed to decode run %s: %w", id, err)
+			}
+			runs = append(runs, run)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].StartedAt.Before(runs[j].StartedAt)
+	})
+
+	return runs, nil
+}
+
+// Update applies fn to the run with the given ID and writes it back in a single transaction,
+// so the changes made to the run by other commands in between are not overwritten
+func (s *Store) Update(id string, fn func(run *Run) error) error {
+	return s.update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(runsBucket)
+		data := bucket.Get([]byte(id))
+		if data == nil {
+			return fmt.Errorf("%w: %s", ErrRunNotFound, id)
+		}
+
+		var run Run
+		if err := json.Unmarshal(data, &run); err != nil {
+			return fmt.Errorf("failed to decode run %s: %w", id, err)
+		}
+		if err := fn(&run); err != nil {
+			return err
+		}
+
+		data, err := json.Marshal(run)
+		if err != nil {
+			return fmt.Errorf("failed to encode run %s: %w", id, err)
+		}
+		if err := bucket.Put([]byte(id), data); err != nil {
+			return fmt.Errorf("failed to write run %s: %w", id, err)
+		}
+		return nil
+	})
+}
+
+// view runs fn in a read only transaction
+func (s *Store) view(fn func(tx *bolt.Tx) error) error {
+	db, err := s.open(true)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.View(fn)
+}
+
+// update runs fn in a read write transaction
+func (s *Store) update(fn func(tx *bolt.Tx) error) error {
+	db, err := s.open(false)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.Update(fn)
+}
+
+// open opens the database, waiting for the lock held by another process
+func (s *Store) open(readOnly bool) (*bolt.DB, error) {
+	db, err := bolt.Open(s.path, 0o644, &bolt.Options{Timeout: openTimeout, ReadOnly: readOnly})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history database '%s': %w", s.path, err)
+	}
+	return db, nil
+}
@@ -0,0 +1,284 @@
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	spawner "github.com/threefoldtech/guardians_healthchecker/spawner/pkg/spawner"
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/types"
+	"gotest.tools/assert"
+)
+
+func TestStore(t *testing.T) {
+	store, err := NewStore(filepath.Join(t.TempDir(), "history.db"))
+	assert.NilError(t, err)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	older := Run{ID: "run-1", Campaign: "nightly", Farms: []uint64{1}, Status: CompletedStatus, StartedAt: now.Add(-time.Hour)}
+	newer := Run{
+		ID:        "run-2",
+		Farms:     []uint64{1, 2},
+		Status:    RunningStatus,
+		StartedAt: now,
+		Spawn: &spawner.SpawnResult{
+			RunID: "run-2",
+			Farms: []spawner.FarmResult{{Farm: 1, Nodes: []spawner.NodeResult{{Node: 11, VMContractID: 110}}}},
+		},
+	}
+
+	t.Run("save and get", func(t *testing.T) {
+		assert.NilError(t, store.Save(newer))
+		assert.NilError(t, store.Save(older))
+
+		run, err := store.Get("run-2")
+		assert.NilError(t, err)
+		assert.DeepEqual(t, run, newer)
+	})
+	t.Run("save replaces the run", func(t *testing.T) {
+		updated := newer
+		updated.Status = CompletedStatus
+		assert.NilError(t, store.Save(updated))
+
+		run, err := store.Get("run-2")
+		assert.NilError(t, err)
+		assert.Equal(t, run.Status, CompletedStatus)
+	})
+	t.Run("list is ordered by start time", func(t *testing.T) {
+		runs, err := store.List()
+		assert.NilError(t, err)
+		assert.Equal(t, len(runs), 2)
+		assert.Equal(t, runs[0].ID, "run-1")
+		assert.Equal(t, runs[1].ID, "run-2")
+	})
+	t.Run("missing run", func(t *testing.T) {
+		_, err := store.Get("missing")
+		assert.Assert(t, errors.Is(err, ErrRunNotFound))
+	})
+	t.Run("update", func(t *testing.T) {
+		err := store.Update("run-1", func(run *Run) error {
+			run.Scores = map[uint32]map[string]float64{11: {"cpu": 1200}}
+			return nil
+		})
+		assert.NilError(t, err)
+
+		run, err := store.Get("run-1")
+		assert.NilError(t, err)
+		assert.Equal(t, run.Campaign, "nightly")
+		assert.DeepEqual(t, run.Scores, map[uint32]map[string]float64{11: {"cpu": 1200}})
+	})
+	t.Run("update keeps the run when fn fails", func(t *testing.T) {
+		err := store.Update("run-1", func(run *Run) error {
+			run.Status = FailedStatus
+			return errors.New("failed")
+		})
+		assert.ErrorContains(t, err, "failed")
+
+		run, err := store.Get("run-1")
+		assert.NilError(t, err)
+		assert.Equal(t, run.Status, CompletedStatus)
+	})
+	t.Run("update missing run", func(t *testing.T) {
+		err := store.Update("missing", func(run *Run) error { return nil })
+		assert.Assert(t, errors.Is(err, ErrRunNotFound))
+	})
+}
+
+func TestMergeSpawn(t *testing.T) {
+	run := Run{
+		ID: "run-1",
+		Spawn: &spawner.SpawnResult{Farms: []spawner.FarmResult{
+			{Farm: 1, Nodes: []spawner.NodeResult{{Node: 11, VMContractID: 110}, {Node: 12, VMContractID: 120}}},
+		}},
+	}
+
+	run.MergeSpawn(spawner.SpawnResult{Farms: []spawner.FarmResult{
+		{Farm: 1, Nodes: []spawner.NodeResult{{Node: 12, VMContractID: 121, Attempts: 1}}},
+		{Farm: 2, Nodes: []spawner.NodeResult{{Node: 21, VMContractID: 210}}},
+	}})
+
+	assert.DeepEqual(t, run.Spawn.Farms, []spawner.FarmResult{
+		{Farm: 1, Nodes: []spawner.NodeResult{{Node: 11, VMContractID: 110}, {Node: 12, VMContractID: 121, Attempts: 1}}},
+		{Farm: 2, Nodes: []spawner.NodeResult{{Node: 21, VMContractID: 210}}},
+	})
+}
+
+func TestDiff(t *testing.T) {
+	from := Run{
+		ID: "run-1",
+		Spawn: &spawner.SpawnResult{Farms: []spawner.FarmResult{
+			{Farm: 1, Eligible: []uint32{11, 12, 13, 14, 16, 17}, Nodes: []spawner.NodeResult{
+				{Node: 11, VMContractID: 110, NetworkContractID: 111},
+				{Node: 12, Error: "deployment timed out"},
+				{Node: 13, VMContractID: 130, NetworkContractID: 131},
+				{Node: 14, VMContractID: 140, NetworkContractID: 141},
+				{Node: 16, VMContractID: 160, NetworkContractID: 161},
+			}},
+			{Farm: 2, Eligible: []uint32{21, 22}},
+			{Farm: 3, Eligible: []uint32{31}},
+		}},
+		Scores: map[uint32]map[string]float64{11: {"cpu": 1}, 13: {"cpu": 1}, 14: {"cpu": 1}, 16: {"cpu": 100, "disk": 40}},
+	}
+	to := Run{
+		ID: "run-2",
+		Spawn: &spawner.SpawnResult{Farms: []spawner.FarmResult{
+			{Farm: 1, Eligible: []uint32{11, 12, 13, 15, 16}, Nodes: []spawner.NodeResult{
+				{Node: 11, Error: "deployment timed out"},
+				{Node: 12, VMContractID: 120, NetworkContractID: 121},
+				{Node: 13, VMContractID: 130, NetworkContractID: 131},
+				{Node: 15, VMContractID: 150, NetworkContractID: 151},
+				{Node: 16, VMContractID: 160, NetworkContractID: 161},
+			}},
+			// none of the nodes is eligible anymore
+			{Farm: 2},
+			// the lookup failed, eligibility is unknown
+			{Farm: 3, Error: "could not fetch nodes from the rmb proxy"},
+		}},
+		Scores: map[uint32]map[string]float64{12: {"cpu": 1}, 16: {"cpu": 75, "disk": 40}},
+	}
+
+	diff := Diff(from, to)
+	assert.DeepEqual(t, diff, RunDiff{From: "run-1", To: "run-2", Changes: []NodeChange{
+		{Farm: 1, Node: 11, From: HealthyState, To: FailingState},
+		{Farm: 1, Node: 12, From: FailingState, To: HealthyState},
+		{Farm: 1, Node: 13, From: HealthyState, To: SilentState},
+	}, Ineligible: []IneligibleNode{
+		{Farm: 1, Node: 14},
+		{Farm: 1, Node: 17},
+		{Farm: 2, Node: 21},
+		{Farm: 2, Node: 22},
+	}, Scores: []ScoreDelta{
+		{Farm: 1, Node: 16, Category: "cpu", From: 100, To: 75, Change: -0.25},
+		{Farm: 1, Node: 16, Category: "disk", From: 40, To: 40, Change: 0},
+	}})
+	assert.Assert(t, diff.Changes[0].Regressed())
+	assert.Assert(t, !diff.Changes[1].Regressed())
+}
+
+func TestQuarantine(t *testing.T) {
+	store, err := NewStore(filepath.Join(t.TempDir(), "history.db"))
+	assert.NilError(t, err)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	cfg := spawner.QuarantineConfig{Threshold: 2, Cooldown: 24 * time.Hour}
+	failing := spawner.SpawnResult{Farms: []spawner.FarmResult{
+		{Farm: 1, Nodes: []spawner.NodeResult{{Node: 11, Error: "deployment timed out"}, {Node: 12, VMContractID: 120, NetworkContractID: 121}}},
+	}}
+
+	t.Run("nodes failing in a row are quarantined", func(t *testing.T) {
+		quarantined, err := store.RecordOutcomes(failing, cfg, now)
+		assert.NilError(t, err)
+		assert.Equal(t, len(quarantined), 0)
+
+		quarantined, err = store.RecordOutcomes(failing, cfg, now)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, quarantined, []QuarantineEntry{{
+			Node:      11,
+			Farm:      1,
+			Reason:    "failed 2 deployments in a row, last error: deployment timed out",
+			Since:     now,
+			Until:     now.Add(24 * time.Hour),
+			Automatic: true,
+		}})
+
+		nodes, err := store.QuarantinedNodes(now)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, nodes, []uint32{11})
+
+		nodes, err = store.QuarantinedNodes(now.Add(25 * time.Hour))
+		assert.NilError(t, err)
+		assert.Equal(t, len(nodes), 0)
+	})
+	t.Run("a success resets the failures", func(t *testing.T) {
+		_, err := store.RecordOutcomes(spawner.SpawnResult{Farms: []spawner.FarmResult{
+			{Farm: 1, Nodes: []spawner.NodeResult{{Node: 13, Error: "deployment timed out"}}},
+		}}, cfg, now)
+		assert.NilError(t, err)
+		_, err = store.RecordOutcomes(spawner.SpawnResult{Farms: []spawner.FarmResult{
+			{Farm: 1, Nodes: []spawner.NodeResult{{Node: 13, VMContractID: 130, NetworkContractID: 131}}},
+		}}, cfg, now)
+		assert.NilError(t, err)
+
+		quarantined, err := store.RecordOutcomes(spawner.SpawnResult{Farms: []spawner.FarmResult{
+			{Farm: 1, Nodes: []spawner.NodeResult{{Node: 13, Error: "deployment timed out"}}},
+		}}, cfg, now)
+		assert.NilError(t, err)
+		assert.Equal(t, len(quarantined), 0)
+	})
+	t.Run("failures not caused by the node are ignored", func(t *testing.T) {
+		notAttributed := spawner.SpawnResult{Farms: []spawner.FarmResult{
+			{Farm: 1, Nodes: []spawner.NodeResult{
+				{Node: 15, Error: "deployment destroyed by destroy-all failure strategy", ErrorClass: spawner.AbortedClass},
+				{Node: 16, Error: "min fee is 2 tft", ErrorClass: spawner.InsufficientBalanceClass},
+			}},
+		}}
+		for i := 0; i < 3; i++ {
+			quarantined, err := store.RecordOutcomes(notAttributed, cfg, now)
+			assert.NilError(t, err)
+			assert.Equal(t, len(quarantined), 0)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		failing := spawner.SpawnResult{Farms: []spawner.FarmResult{
+			{Farm: 1, Nodes: []spawner.NodeResult{{Node: 17, Error: "deployment timed out", ErrorClass: spawner.TimeoutClass}}},
+		}}
+		store.TrackOutcomes(ctx, failing, cfg)
+		store.TrackOutcomes(ctx, failing, cfg)
+
+		nodes, err := store.QuarantinedNodes(now)
+		assert.NilError(t, err)
+		assert.Assert(t, !slices.Contains(nodes, 15) && !slices.Contains(nodes, 16) && !slices.Contains(nodes, 17))
+	})
+	t.Run("add and release", func(t *testing.T) {
+		assert.NilError(t, store.Quarantine(QuarantineEntry{Node: 14, Reason: "faulty disk", Since: now}))
+
+		entries, err := store.Quarantines()
+		assert.NilError(t, err)
+		assert.Equal(t, len(entries), 2)
+		assert.Assert(t, entries[1].Active(now.Add(1000*time.Hour)))
+
+		assert.NilError(t, store.Release(14))
+		err = store.Release(14)
+		assert.Assert(t, errors.Is(err, ErrNotQuarantined))
+	})
+}
+
+func TestDestroyOptions(t *testing.T) {
+	run := Run{ID: "run-1", Spawn: &spawner.SpawnResult{Farms: []spawner.FarmResult{
+		{Farm: 1, Nodes: []spawner.NodeResult{
+			{Node: 11, NetworkContractID: 110, VMContractID: 111},
+			{Node: 12, NetworkContractID: 120, Error: "vm failed"},
+			{Node: 13, Error: "node is unreachable"},
+		}},
+	}}}
+
+	assert.DeepEqual(t, run.DestroyOptions(), spawner.DestroyOptions{Contracts: []uint64{110, 111, 120}})
+	assert.DeepEqual(t, Run{ID: "run-2"}.DestroyOptions(), spawner.DestroyOptions{RunID: "run-2"})
+}
+
+func TestInventory(t *testing.T) {
+	var run Run
+	run.SetSpawn(spawner.SpawnResult{Farms: []spawner.FarmResult{
+		{Farm: 1, Inventory: []types.Node{{NodeID: 11, FarmID: 1, Country: "Belgium"}, {NodeID: 12, FarmID: 1}}},
+		{Farm: 2, Inventory: []types.Node{{NodeID: 21, FarmID: 2, CertificationType: "Certified"}}},
+	}})
+
+	records := run.NodeRecords()
+	assert.Equal(t, len(records), 3)
+	assert.Equal(t, records[11].Country, "Belgium")
+	assert.Equal(t, records[21].CertificationType, "Certified")
+	assert.Equal(t, len(Run{}.NodeRecords()), 0)
+
+	// the records are kept by the run only, not by the spawn result
+	data, err := json.Marshal(run)
+	assert.NilError(t, err)
+	var decoded Run
+	assert.NilError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, len(decoded.NodeRecords()), 3)
+	assert.Equal(t, len(decoded.Spawn.Farms[0].Inventory), 0)
+}
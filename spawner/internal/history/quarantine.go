@@ -0,0 +1,259 @@
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	spawner "github.com/threefoldtech/guardians_healthchecker/spawner/pkg/spawner"
+	bolt "go.etcd.io/bbolt"
+)
+
+// ErrNotQuarantined is returned when releasing a node which is not quarantined
+var ErrNotQuarantined = errors.New("node is not quarantined")
+
+// Buckets of the quarantined nodes and of the deployment failures of every node, keyed by node ID
+var (
+	quarantineBucket = []byte("quarantine")
+	failuresBucket   = []byte("failures")
+)
+
+// QuarantineEntry is a node excluded from the runs
+type QuarantineEntry struct {
+	Node   uint32    `json:"node"`
+	Farm   uint64    `json:"farm,omitempty"`
+	Reason string    `json:"reason"`
+	Since  time.Time `json:"since"`
+	// Until is when the node is released, a zero time keeps the node quarantined until it is removed
+	Until time.Time `json:"until,omitempty"`
+	// Automatic is set if the node was quarantined for failing its deployments
+	Automatic bool `json:"automatic"`
+}
+
+// Active reports whether the node is still quarantined at the given time
+func (e QuarantineEntry) Active(now time.Time) bool {
+	return e.Until.IsZero() || now.Before(e.Until)
+}
+
+// nodeFailures counts the deployments a node failed in a row
+type nodeFailures struct {
+	Consecutive int       `json:"consecutive"`
+	LastError   string    `json:"last_error"`
+	LastFailure time.Time `json:"last_failure"`
+}
+
+// Quarantine adds the node to the quarantine, replacing any earlier entry of the node
+func (s *Store) Quarantine(entry QuarantineEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode quarantine of node %d: %w", entry.Node, err)
+	}
+
+	err = s.update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(quarantineBucket)
+		if err != nil {
+			return err
+		}
+		return bucket.Put(nodeKey(entry.Node), data)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to quarantine node %d: %w", entry.Node, err)
+	}
+
+	return nil
+}
+
+// Release removes the node from the quarantine and resets its failures
+func (s *Store) Release(node uint32) error {
+	return s.update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(quarantineBucket)
+		if bucket == nil || bucket.Get(nodeKey(node)) == nil {
+			return fmt.Errorf("%w: %d", ErrNotQuarantined, node)
+		}
+		if err := bucket.Delete(nodeKey(node)); err != nil {
+			return err
+		}
+
+		if failures := tx.Bucket(failuresBucket); failures != nil {
+			return failures.Delete(nodeKey(node))
+		}
+		return nil
+	})
+}
+
+// Quarantines returns every quarantine entry, including the expired ones, ordered by node
+func (s *Store) Quarantines() ([]QuarantineEntry, error) {
+	var entries []QuarantineEntry
+	err := s.view(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(quarantineBucket)
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(node, data []byte) error {
+			var entry QuarantineEntry
+			if err := json.Unmarshal(data, &entry); err != nil {
+				return fmt.Errorf("failed to decode quarantine of node %s: %w", node, err)
+			}
+			entries = append(entries, entry)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Node < entries[j].Node
+	})
+
+	return entries, nil
+}
+
+// QuarantinedNodes returns the nodes quarantined at the given time
+func (s *Store) QuarantinedNodes(now time.Time) ([]uint32, error) {
+	entries, err := s.Quarantines()
+	if err != nil {
+		return nil, err
+	}
+
+	var nodes []uint32
+	for _, entry := range entries {
+		if entry.Active(now) {
+			nodes = append(nodes, entry.Node)
+		}
+	}
+
+	return nodes, nil
+}
+
+// ExcludedNodes returns the nodes currently quarantined to exclude from a run, failing to read them only logs
+// a warning so a broken history does not stop the runs
+func (s *Store) ExcludedNodes() []uint32 {
+	nodes, err := s.QuarantinedNodes(time.Now())
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to read the quarantined nodes, no node is excluded")
+		return nil
+	}
+	if len(nodes) != 0 {
+		log.Info().Uints32("Nodes", nodes).Msg("excluding quarantined nodes")
+	}
+
+	return nodes
+}
+
+// TrackOutcomes records the outcomes of the spawn result and logs the newly quarantined nodes, failing to record
+// them only logs an error. The outcomes of a cancelled spawn are not recorded as its nodes were stopped.
+func (s *Store) TrackOutcomes(ctx context.Context, result spawner.SpawnResult, cfg spawner.QuarantineConfig) {
+	if ctx.Err() != nil {
+		return
+	}
+
+	quarantined, err := s.RecordOutcomes(result, cfg, time.Now())
+	if err != nil {
+		log.Error().Err(err).Str("Run", result.RunID).Msg("failed to record the deployment outcomes")
+	}
+	for _, entry := range quarantined {
+		log.Warn().Uint32("Node", entry.Node).Time("Until", entry.Until).Str("Reason", entry.Reason).Msg("node quarantined")
+	}
+}
+
+// RecordOutcomes counts the deployments every node of the spawn result failed in a row and quarantines the
+// nodes reaching the configured threshold for the configured cooldown, it returns the newly quarantined nodes.
+// The failures which are not caused by the node, such as aborted deployments, are not counted.
+func (s *Store) RecordOutcomes(result spawner.SpawnResult, cfg spawner.QuarantineConfig, now time.Time) ([]QuarantineEntry, error) {
+	var quarantined []QuarantineEntry
+	err := s.update(func(tx *bolt.Tx) error {
+		failures, err := tx.CreateBucketIfNotExists(failuresBucket)
+		if err != nil {
+			return err
+		}
+		quarantine, err := tx.CreateBucketIfNotExists(quarantineBucket)
+		if err != nil {
+			return err
+		}
+
+		for _, farm := range result.Farms {
+			for _, node := range farm.Nodes {
+				key := nodeKey(node.Node)
+				if !node.Succeeded() && !node.ErrorClass.NodeAttributed() {
+					continue
+				}
+				if node.Succeeded() {
+					if err := failures.Delete(key); err != nil {
+						return err
+					}
+					continue
+				}
+
+				var counter nodeFailures
+				if data := failures.Get(key); data != nil {
+					if err := json.Unmarshal(data, &counter); err != nil {
+						return fmt.Errorf("failed to decode failures of node %d: %w", node.Node, err)
+					}
+				}
+				counter.Consecutive++
+				counter.LastError = node.Error
+				counter.LastFailure = now
+
+				if cfg.Threshold > 0 && counter.Consecutive >= cfg.Threshold && !activeEntry(quarantine, key, now) {
+					entry := QuarantineEntry{
+						Node:      node.Node,
+						Farm:      farm.Farm,
+						Reason:    fmt.Sprintf("failed %d deployments in a row, last error: %s", counter.Consecutive, node.Error),
+						Since:     now,
+						Until:     now.Add(cfg.Cooldown),
+						Automatic: true,
+					}
+					data, err := json.Marshal(entry)
+					if err != nil {
+						return err
+					}
+					if err := quarantine.Put(key, data); err != nil {
+						return err
+					}
+					quarantined = append(quarantined, entry)
+					// the node gets a fresh start once its cooldown is over
+					counter = nodeFailures{}
+				}
+
+				data, err := json.Marshal(counter)
+				if err != nil {
+					return err
+				}
+				if err := failures.Put(key, data); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record the deployment outcomes: %w", err)
+	}
+
+	return quarantined, nil
+}
+
+// activeEntry reports whether the bucket holds an active quarantine entry under key
+func activeEntry(bucket *bolt.Bucket, key []byte, now time.Time) bool {
+	data := bucket.Get(key)
+	if data == nil {
+		return false
+	}
+
+	var entry QuarantineEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return false
+	}
+	return entry.Active(now)
+}
+
+// nodeKey returns the key of a node in the node buckets
+func nodeKey(node uint32) []byte {
+	return []byte(strconv.FormatUint(uint64(node), 10))
+}
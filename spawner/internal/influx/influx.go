@@ -0,0 +1,220 @@
+package influx
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	spawner "github.com/threefoldtech/guardians_healthchecker/spawner/pkg/spawner"
+)
+
+// Represents the tags shared with the points written by the benchmark VMs
+const (
+	NodeTag = "NODE_ID"
+	FarmTag = "FARM_ID"
+	RunTag  = "run_id"
+)
+
+// DeploymentMeasurement is the measurement of the node deployment points written by spawner
+const DeploymentMeasurement = "spawner_deployment"
+
+// requestTimeout bounds every request to InfluxDB
+const requestTimeout = 30 * time.Second
+
+// Point is a single InfluxDB point
+type Point struct {
+	Measurement string
+	Tags        map[string]string
+	Fields      map[string]any
+	Time        time.Time
+}
+
+// Client talks to the InfluxDB v2 HTTP API of the configured bucket
+type Client struct {
+	cfg  spawner.InfluxConfig
+	http *http.Client
+}
+
+// New returns a client of the configured InfluxDB bucket
+func New(cfg spawner.InfluxConfig) *Client {
+	return &Client{
+		cfg:  cfg,
+		http: &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// Write writes the points to the bucket
+func (c *Client) Write(ctx context.Context, points []Point) error {
+	if len(points) == 0 {
+		return nil
+	}
+
+	var body bytes.Buffer
+	for _, point := range points {
+		body.WriteString(point.line())
+		body.WriteByte('\n')
+	}
+
+	query := url.Values{}
+	query.Set("org", c.cfg.Org)
+	query.Set("bucket", c.cfg.Bucket)
+	query.Set("precision", "ns")
+
+	_, err := c.do(ctx, "/api/v2/write?"+query.Encode(), "text/plain; charset=utf-8", &body)
+	if err != nil {
+		return fmt.Errorf("failed to write %d points to influx: %w", len(points), err)
+	}
+
+	return nil
+}
+
+// WriteSpawn writes a point for the deployment of every node of the spawn result
+func (c *Client) WriteSpawn(ctx context.Context, result spawner.SpawnResult) error {
+	return c.Write(ctx, SpawnPoints(result, time.Now()))
+}
+
+// SpawnPoints returns a point for the deployment of every node of the spawn result, tagged like
+// the benchmark results so the deployment health of a node sits next to them
+func SpawnPoints(result spawner.SpawnResult, now time.Time) []Point {
+	var points []Point
+	for _, farm := range result.Farms {
+		for _, node := range farm.Nodes {
+			retries := node.Attempts - 1
+			if retries < 0 {
+				retries = 0
+			}
+
+			tags := map[string]string{
+				NodeTag: strconv.FormatUint(uint64(node.Node), 10),
+				FarmTag: strconv.FormatUint(farm.Farm, 10),
+				RunTag:  result.RunID,
+			}
+			if node.ErrorClass != "" {
+				tags["error_class"] = string(node.ErrorClass)
+			}
+
+			fields := map[string]any{
+				"success":          node.Succeeded(),
+				"duration_seconds": node.Duration.Seconds(),
+				"network_seconds":  node.NetworkDuration.Seconds(),
+				"vm_seconds":       node.VMDuration.Seconds(),
+				"attempts":         node.Attempts,
+				"retries":          retries,
+			}
+			if node.Error != "" {
+				fields["error"] = node.Error
+			}
+
+			points = append(points, Point{Measurement: DeploymentMeasurement, Tags: tags, Fields: fields, Time: now})
+		}
+	}
+
+	return points
+}
+
+// do sends a request to the InfluxDB API and returns the response body
+func (c *Client) do(ctx context.Context, path, contentType string, body io.Reader) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(c.cfg.URL, "/")+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Token "+c.cfg.Token)
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("influx responded with %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+
+	return data, nil
+}
+
+// line encodes the point in the InfluxDB line protocol
+func (p Point) line() string {
+	var b strings.Builder
+	b.WriteString(measurementEscaper.Replace(p.Measurement))
+
+	for _, key := range sortedKeys(p.Tags) {
+		if p.Tags[key] == "" {
+			continue
+		}
+		b.WriteByte(',')
+		b.WriteString(keyEscaper.Replace(key))
+		b.WriteByte('=')
+		b.WriteString(keyEscaper.Replace(p.Tags[key]))
+	}
+
+	for i, key := range sortedKeys(p.Fields) {
+		if i == 0 {
+			b.WriteByte(' ')
+		} else {
+			b.WriteByte(',')
+		}
+		b.WriteString(keyEscaper.Replace(key))
+		b.WriteByte('=')
+		b.WriteString(fieldValue(p.Fields[key]))
+	}
+
+	if !p.Time.IsZero() {
+		b.WriteByte(' ')
+		b.WriteString(strconv.FormatInt(p.Time.UnixNano(), 10))
+	}
+
+	return b.String()
+}
+
+// Escapers of the line protocol elements
+var (
+	measurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `)
+	keyEscaper         = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+	stringEscaper      = strings.NewReplacer(`"`, `\"`, `\`, `\\`)
+)
+
+// fieldValue encodes a field value in the line protocol
+func fieldValue(v any) string {
+	switch v := v.(type) {
+	case bool:
+		return strconv.FormatBool(v)
+	case int:
+		return strconv.Itoa(v) + "i"
+	case int64:
+		return strconv.FormatInt(v, 10) + "i"
+	case uint32:
+		return strconv.FormatUint(uint64(v), 10) + "i"
+	case uint64:
+		return strconv.FormatUint(v, 10) + "i"
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		return `"` + stringEscaper.Replace(v) + `"`
+	default:
+		return `"` + stringEscaper.Replace(fmt.Sprint(v)) + `"`
+	}
+}
+
+// sortedKeys returns the keys of m in order so the encoded points are stable
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,89 @@
+package influx
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	spawner "github.com/threefoldtech/guardians_healthchecker/spawner/pkg/spawner"
+	"gotest.tools/assert"
+)
+
+func TestPointLine(t *testing.T) {
+	point := Point{
+		Measurement: "spawner deployment",
+		Tags:        map[string]string{"NODE_ID": "11", "error_class": "", "run_id": "run 1"},
+		Fields:      map[string]any{"success": false, "attempts": 2, "duration_seconds": 1.5, "error": `node "11" is down`},
+		Time:        time.Unix(0, 42),
+	}
+
+	assert.Equal(t, point.line(), `spawner\ deployment,NODE_ID=11,run_id=run\ 1 attempts=2i,duration_seconds=1.5,error="node \"11\" is down",success=false 42`)
+}
+
+func TestWriteSpawn(t *testing.T) {
+	var body, query, auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body, query, auth = string(data), r.URL.RawQuery, r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	client := New(spawner.InfluxConfig{URL: srv.URL, Org: "org", Token: "token", Bucket: "bucket"})
+	result := spawner.SpawnResult{
+		RunID: "run-1",
+		Farms: []spawner.FarmResult{{
+			Farm:  1,
+			Nodes: []spawner.NodeResult{{Node: 11, VMContractID: 110, Attempts: 1, Duration: time.Second}},
+		}},
+	}
+
+	err := client.WriteSpawn(context.Background(), result)
+	assert.NilError(t, err)
+	assert.Equal(t, auth, "Token token")
+	assert.Equal(t, query, "bucket=bucket&org=org&precision=ns")
+	assert.Assert(t, strings.HasPrefix(body, "spawner_deployment,FARM_ID=1,NODE_ID=11,run_id=run-1 attempts=1i,duration_seconds=1,"), body)
+	assert.Assert(t, strings.Contains(body, ",retries=0i,success=true,"), body)
+}
+
+func TestWriteError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unauthorized access", http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	client := New(spawner.InfluxConfig{URL: srv.URL, Org: "org", Token: "wrong", Bucket: "bucket"})
+	err := client.Write(context.Background(), []Point{{Measurement: "m", Fields: map[string]any{"v": 1}}})
+	assert.ErrorContains(t, err, "unauthorized access")
+}
+
+func TestQueryScores(t *testing.T) {
+	response := "" +
+		",result,table,_start,_stop,_time,_value,_field,_measurement,NODE_ID\r\n" +
+		",_result,0,2024-01-01T00:00:00Z,2024-01-02T00:00:00Z,2024-01-01T01:00:00Z,1200.5,score,cpu,11\r\n" +
+		",_result,1,2024-01-01T00:00:00Z,2024-01-02T00:00:00Z,2024-01-01T01:00:00Z,830,score,disk,11\r\n" +
+		"\r\n" +
+		",result,table,_start,_stop,_time,_value,_field,_measurement,NODE_ID\r\n" +
+		",_result,2,2024-01-01T00:00:00Z,2024-01-02T00:00:00Z,2024-01-01T01:00:00Z,990,score,cpu,12\r\n"
+
+	var query string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		query = string(data)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer srv.Close()
+
+	client := New(spawner.InfluxConfig{URL: srv.URL, Org: "org", Token: "token", Bucket: "bucket"})
+	scores, err := client.QueryScores(context.Background(), time.Unix(0, 0), time.Unix(3600, 0))
+	assert.NilError(t, err)
+	assert.Assert(t, strings.Contains(query, `from(bucket: \"bucket\")`), query)
+	assert.DeepEqual(t, scores, map[uint32]map[string]float64{
+		11: {"cpu": 1200.5, "disk": 830},
+		12: {"cpu": 990},
+	})
+}
@@ -0,0 +1,183 @@
+package influx
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Represents the benchmark results written by the benchmark VMs, every category is a measurement
+// holding the score of the node in the score field
+const (
+	CPUCategory     = "cpu"
+	MemoryCategory  = "memory"
+	DiskCategory    = "disk"
+	NetworkCategory = "network"
+
+	ScoreField = "score"
+)
+
+// Categories are the benchmark categories in display order
+var Categories = []string{CPUCategory, MemoryCategory, DiskCategory, NetworkCategory}
+
+// Record is a row of a Flux query result keyed by column name
+type Record map[string]string
+
+// queryRequest is the body of a Flux query request
+type queryRequest struct {
+	Query   string       `json:"query"`
+	Type    string       `json:"type"`
+	Dialect queryDialect `json:"dialect"`
+}
+
+// queryDialect sets the CSV format of the query response
+type queryDialect struct {
+	Header      bool     `json:"header"`
+	Annotations []string `json:"annotations"`
+}
+
+// Query runs a Flux query and returns the rows of all the result tables
+func (c *Client) Query(ctx context.Context, flux string) ([]Record, error) {
+	body, err := json.Marshal(queryRequest{
+		Query:   flux,
+		Type:    "flux",
+		Dialect: queryDialect{Header: true, Annotations: []string{}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	query.Set("org", c.cfg.Org)
+
+	data, err := c.do(ctx, "/api/v2/query?"+query.Encode(), "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query influx: %w", err)
+	}
+
+	return parseCSV(data)
+}
+
+// QueryScores returns the last score of every benchmark category reported by every node between start and stop
+func (c *Client) QueryScores(ctx context.Context, start, stop time.Time) (map[uint32]map[string]float64, error) {
+	measurements := make([]string, 0, len(Categories))
+	for _, category := range Categories {
+		measurements = append(measurements, fmt.Sprintf("r._measurement == %q", category))
+	}
+
+	flux := fmt.Sprintf(`from(bucket: %q)
+  |> range(start: %s, stop: %s)
+  |> filter(fn: (r) => %s)
+  |> filter(fn: (r) => r._field == %q)
+  |> group(columns: [%q, "_measurement"])
+  |> last()`,
+		c.cfg.Bucket, start.UTC().Format(time.RFC3339), stop.UTC().Format(time.RFC3339),
+		strings.Join(measurements, " or "), ScoreField, NodeTag,
+	)
+
+	records, err := c.Query(ctx, flux)
+	if err != nil {
+		return nil, err
+	}
+
+	scores := make(map[uint32]map[string]float64)
+	for _, record := range records {
+		node, err := strconv.ParseUint(record[NodeTag], 10, 32)
+		if err != nil {
+			continue
+		}
+		value, err := strconv.ParseFloat(record["_value"], 64)
+		if err != nil {
+			continue
+		}
+
+		if scores[uint32(node)] == nil {
+			scores[uint32(node)] = make(map[string]float64)
+		}
+		scores[uint32(node)][record["_measurement"]] = value
+	}
+
+	return scores, nil
+}
+
+// QueryReportingNodes returns the nodes which wrote any benchmark point between start and stop
+func (c *Client) QueryReportingNodes(ctx context.Context, start, stop time.Time) (map[uint32]bool, error) {
+	return c.queryNodes(ctx, start, stop, fmt.Sprintf("r._measurement != %q", DeploymentMeasurement))
+}
+
+// queryNodes returns the nodes of the points matching the Flux predicate written between start and stop
+func (c *Client) queryNodes(ctx context.Context, start, stop time.Time, predicate string) (map[uint32]bool, error) {
+	flux := fmt.Sprintf(`import "influxdata/influxdb/schema"
+
+schema.tagValues(
+  bucket: %q,
+  tag: %q,
+  predicate: (r) => %s,
+  start: %s,
+  stop: %s,
+)`,
+		c.cfg.Bucket, NodeTag, predicate, start.UTC().Format(time.RFC3339), stop.UTC().Format(time.RFC3339),
+	)
+
+	records, err := c.Query(ctx, flux)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make(map[uint32]bool, len(records))
+	for _, record := range records {
+		node, err := strconv.ParseUint(record["_value"], 10, 32)
+		if err != nil {
+			continue
+		}
+		nodes[uint32(node)] = true
+	}
+
+	return nodes, nil
+}
+
+// parseCSV parses the CSV response of a Flux query, each result table starts with its own header row
+func parseCSV(data []byte) ([]Record, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+
+	var (
+		records []Record
+		header  []string
+	)
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse influx response: %w", err)
+		}
+
+		if header == nil || len(row) != len(header) || isHeader(row) {
+			header = row
+			continue
+		}
+
+		record := make(Record, len(row))
+		for i, column := range header {
+			record[column] = row[i]
+		}
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// isHeader reports whether the row is the header row of a result table
+func isHeader(row []string) bool {
+	return len(row) > 1 && row[1] == "result"
+}
@@ -0,0 +1,178 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rs/zerolog/log"
+	spawner "github.com/threefoldtech/guardians_healthchecker/spawner/pkg/spawner"
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-client/workloads"
+)
+
+// namespace prefixes the names of all the spawner metrics
+const namespace = "spawner"
+
+// Metrics holds the Prometheus collectors of the spawner operations
+type Metrics struct {
+	registry *prometheus.Registry
+
+	deployments        *prometheus.CounterVec
+	retries            *prometheus.CounterVec
+	stageDuration      *prometheus.HistogramVec
+	liveVMs            *prometheus.GaugeVec
+	inventoryErrors    prometheus.Counter
+	destroys           *prometheus.CounterVec
+	cancelledContracts *prometheus.CounterVec
+}
+
+// New returns the spawner metrics registered in a new registry
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		deployments: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "deployments_total",
+			Help:      "Number of node deployments by farm, result and error class.",
+		}, []string{"farm", "result", "error_class"}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "deployment_retries_total",
+			Help:      "Number of node deployment retries by farm.",
+		}, []string{"farm"}),
+		stageDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "deployment_stage_duration_seconds",
+			Help:      "Time spent deploying a node by stage, network or vm.",
+			Buckets:   []float64{5, 10, 30, 60, 120, 300, 600, 1200},
+		}, []string{"stage"}),
+		liveVMs: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "live_vms",
+			Help:      "Number of benchmark VMs currently deployed by farm.",
+		}, []string{"farm"}),
+		inventoryErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "inventory_errors_total",
+			Help:      "Number of inventory refreshes which could not list every benchmark deployment.",
+		}),
+		destroys: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "destroys_total",
+			Help:      "Number of farm destroys by farm and result.",
+		}, []string{"farm", "result"}),
+		cancelledContracts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cancelled_contracts_total",
+			Help:      "Number of benchmark contracts cancelled by farm.",
+		}, []string{"farm"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.deployments,
+		m.retries,
+		m.stageDuration,
+		m.liveVMs,
+		m.inventoryErrors,
+		m.destroys,
+		m.cancelledContracts,
+	)
+
+	return m
+}
+
+// Handler returns the HTTP handler exposing the metrics
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// ObserveSpawn records the outcome of every node deployment of a spawn run
+func (m *Metrics) ObserveSpawn(result spawner.SpawnResult) {
+	for _, farm := range result.Farms {
+		farmID := strconv.FormatUint(farm.Farm, 10)
+		for _, node := range farm.Nodes {
+			outcome := "succeeded"
+			if !node.Succeeded() {
+				outcome = "failed"
+			}
+			m.deployments.WithLabelValues(farmID, outcome, string(node.ErrorClass)).Inc()
+
+			if node.Attempts > 1 {
+				m.retries.WithLabelValues(farmID).Add(float64(node.Attempts - 1))
+			}
+			if node.NetworkDuration != 0 {
+				m.stageDuration.WithLabelValues("network").Observe(node.NetworkDuration.Seconds())
+			}
+			if node.VMDuration != 0 {
+				m.stageDuration.WithLabelValues("vm").Observe(node.VMDuration.Seconds())
+			}
+		}
+	}
+}
+
+// ObserveDestroy records the outcome of destroying the deployments of every farm
+func (m *Metrics) ObserveDestroy(result spawner.DestroyResult) {
+	for _, farm := range result.Farms {
+		farmID := strconv.FormatUint(farm.Farm, 10)
+		outcome := "succeeded"
+		if farm.Error != "" {
+			outcome = "failed"
+		}
+		m.destroys.WithLabelValues(farmID, outcome).Inc()
+		m.cancelledContracts.WithLabelValues(farmID).Add(float64(len(farm.Cancelled)))
+	}
+}
+
+// SetInventory sets the number of live benchmark VMs of every farm from the listed deployments
+func (m *Metrics) SetInventory(farms []uint64, vms []spawner.VMInfo) {
+	counts := make(map[uint64]int, len(farms))
+	for _, farm := range farms {
+		counts[farm] = 0
+	}
+	for _, vm := range vms {
+		if vm.Type == workloads.VMType {
+			counts[vm.Farm]++
+		}
+	}
+
+	for farm, count := range counts {
+		m.liveVMs.WithLabelValues(strconv.FormatUint(farm, 10)).Set(float64(count))
+	}
+}
+
+// ObserveInventory sets the number of live benchmark VMs from the deployments listed by an inventory refresh,
+// a refresh which failed to list some deployments still sets the ones it listed and counts as an error
+func (m *Metrics) ObserveInventory(farms []uint64, vms []spawner.VMInfo, err error) {
+	if err != nil {
+		m.inventoryErrors.Inc()
+	}
+	m.SetInventory(farms, vms)
+}
+
+// WatchInventory refreshes the number of live benchmark VMs of the farms every interval until ctx is done
+func (m *Metrics) WatchInventory(ctx context.Context, interval time.Duration, farms []uint64, inventory func(ctx context.Context) ([]spawner.VMInfo, error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		vms, err := inventory(ctx)
+		if ctx.Err() == nil {
+			if err != nil {
+				log.Warn().Err(err).Msg("failed to refresh the whole benchmark VMs inventory")
+			}
+			m.ObserveInventory(farms, vms, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	spawner "github.com/threefoldtech/guardians_healthchecker/spawner/pkg/spawner"
+	"gotest.tools/assert"
+)
+
+func TestObserveSpawn(t *testing.T) {
+	m := New()
+	m.ObserveSpawn(spawner.SpawnResult{
+		Farms: []spawner.FarmResult{{
+			Farm: 1,
+			Nodes: []spawner.NodeResult{
+				{Node: 11, VMContractID: 110, Attempts: 1, NetworkDuration: time.Second, VMDuration: time.Second},
+				{Node: 12, Attempts: 3, Error: "out of capacity", ErrorClass: spawner.InsufficientCapacityClass, NetworkDuration: time.Second},
+			},
+		}},
+	})
+
+	assert.Equal(t, testutil.ToFloat64(m.deployments.WithLabelValues("1", "succeeded", "")), 1.0)
+	assert.Equal(t, testutil.ToFloat64(m.deployments.WithLabelValues("1", "failed", string(spawner.InsufficientCapacityClass))), 1.0)
+	assert.Equal(t, testutil.ToFloat64(m.retries.WithLabelValues("1")), 2.0)
+	assert.Equal(t, testutil.CollectAndCount(m.stageDuration), 2)
+}
+
+func TestSetInventory(t *testing.T) {
+	m := New()
+	m.SetInventory([]uint64{1, 2}, []spawner.VMInfo{
+		{Farm: 1, Node: 11, Type: "network"},
+		{Farm: 1, Node: 11, Type: "vm"},
+		{Farm: 1, Node: 12, Type: "vm"},
+	})
+
+	assert.Equal(t, testutil.ToFloat64(m.liveVMs.WithLabelValues("1")), 2.0)
+	assert.Equal(t, testutil.ToFloat64(m.liveVMs.WithLabelValues("2")), 0.0)
+}
+
+func TestObserveInventory(t *testing.T) {
+	m := New()
+	m.ObserveInventory([]uint64{1}, []spawner.VMInfo{{Farm: 1, Node: 11, Type: "vm"}}, nil)
+	assert.Equal(t, testutil.ToFloat64(m.liveVMs.WithLabelValues("1")), 1.0)
+	assert.Equal(t, testutil.ToFloat64(m.inventoryErrors), 0.0)
+
+	m.ObserveInventory([]uint64{1}, []spawner.VMInfo{
+		{Farm: 1, Node: 11, Type: "vm"},
+		{Farm: 1, Node: 12, Type: "vm", Error: "node unreachable"},
+	}, errors.New("node unreachable"))
+	assert.Equal(t, testutil.ToFloat64(m.liveVMs.WithLabelValues("1")), 2.0)
+	assert.Equal(t, testutil.ToFloat64(m.inventoryErrors), 1.0)
+}
@@ -3,6 +3,7 @@ package parser
 import (
 	"strings"
 	"testing"
+	"time"
 
 	// "github.com/stretchr/testify/assert"
 	types "github.com/threefoldtech/guardians_healthchecker/spawner/pkg/spawner"
@@ -29,6 +30,15 @@ func TestParseConfig(t *testing.T) {
 			Token:  "example_token",
 			Bucket: "example_bucket",
 		},
+		TTL: 24 * time.Hour,
+		Campaigns: []types.Campaign{
+			{Name: "nightly", Schedule: "0 2 * * *", Duration: 2 * time.Hour},
+		},
+		Quarantine: types.QuarantineConfig{Threshold: 3, Cooldown: 72 * time.Hour},
+		Profile:    "large",
+		Profiles: map[string]types.VMProfile{
+			"large": {CPU: 8, Memory: 16, RootSize: 100},
+		},
 	}
 	t.Run("valid config", func(t *testing.T) {
 		conf := confStruct
@@ -85,6 +95,54 @@ func TestParseConfig(t *testing.T) {
 		_, err = ParseConfig(configFile)
 		assert.Error(t, err, err.Error())
 	})
+	t.Run("invalid ttl", func(t *testing.T) {
+		conf := confStruct
+		conf.TTL = -time.Hour
+
+		data, err := yaml.Marshal(conf)
+		assert.NilError(t, err)
+
+		configFile := strings.NewReader(string(data))
+
+		_, err = ParseConfig(configFile)
+		assert.Error(t, err, err.Error())
+	})
+	t.Run("invalid campaign schedule", func(t *testing.T) {
+		conf := confStruct
+		conf.Campaigns = []types.Campaign{{Name: "nightly", Schedule: "every night", Duration: time.Hour}}
+
+		data, err := yaml.Marshal(conf)
+		assert.NilError(t, err)
+
+		configFile := strings.NewReader(string(data))
+
+		_, err = ParseConfig(configFile)
+		assert.Error(t, err, err.Error())
+	})
+	t.Run("invalid quarantine cooldown", func(t *testing.T) {
+		conf := confStruct
+		conf.Quarantine = types.QuarantineConfig{Threshold: 3}
+
+		data, err := yaml.Marshal(conf)
+		assert.NilError(t, err)
+
+		configFile := strings.NewReader(string(data))
+
+		_, err = ParseConfig(configFile)
+		assert.Error(t, err, err.Error())
+	})
+	t.Run("unknown profile", func(t *testing.T) {
+		conf := confStruct
+		conf.Profile = "huge"
+
+		data, err := yaml.Marshal(conf)
+		assert.NilError(t, err)
+
+		configFile := strings.NewReader(string(data))
+
+		_, err = ParseConfig(configFile)
+		assert.Error(t, err, err.Error())
+	})
 	t.Run("invalid influx config", func(t *testing.T) {
 		conf := confStruct
 		conf.Influx.URL = "invalid url"
@@ -4,8 +4,10 @@ import (
 	"fmt"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/cosmos/go-bip39"
+	"github.com/robfig/cron/v3"
 	types "github.com/threefoldtech/guardians_healthchecker/spawner/pkg/spawner"
 )
 
@@ -85,6 +87,63 @@ func validateInfluxConfig(config types.InfluxConfig) error {
 	return nil
 }
 
+// validateTTL ensures the TTL of the spawned VMs is not negative
+func validateTTL(ttl time.Duration) error {
+	if ttl < 0 {
+		return fmt.Errorf("invalid ttl: %s, must be positive", ttl)
+	}
+	return nil
+}
+
+// validateCampaigns ensures every campaign has a unique name, a valid cron schedule and a positive duration
+func validateCampaigns(campaigns []types.Campaign) error {
+	names := make(map[string]bool)
+	for _, campaign := range campaigns {
+		if strings.TrimSpace(campaign.Name) == "" {
+			return fmt.Errorf("campaign name cannot be empty")
+		}
+		if names[campaign.Name] {
+			return fmt.Errorf("duplicate campaign name: %s", campaign.Name)
+		}
+		names[campaign.Name] = true
+
+		if _, err := cron.ParseStandard(campaign.Schedule); err != nil {
+			return fmt.Errorf("invalid schedule of campaign %s: %w", campaign.Name, err)
+		}
+		if campaign.Duration <= 0 {
+			return fmt.Errorf("invalid duration of campaign %s: %s, must be positive", campaign.Name, campaign.Duration)
+		}
+		if err := validateFarms(campaign.Farms); err != nil {
+			return fmt.Errorf("invalid farms of campaign %s: %w", campaign.Name, err)
+		}
+	}
+	return nil
+}
+
+// validateQuarantine ensures the automatic quarantine has a positive cooldown when it is enabled
+func validateQuarantine(quarantine types.QuarantineConfig) error {
+	if quarantine.Threshold < 0 {
+		return fmt.Errorf("invalid quarantine threshold: %d, must be positive", quarantine.Threshold)
+	}
+	if quarantine.Threshold > 0 && quarantine.Cooldown <= 0 {
+		return fmt.Errorf("invalid quarantine cooldown: %s, must be positive", quarantine.Cooldown)
+	}
+	return nil
+}
+
+// validateProfiles ensures every VM profile has positive resources and the selected profile exists
+func validateProfiles(selected string, profiles map[string]types.VMProfile) error {
+	for name, profile := range profiles {
+		if profile.CPU <= 0 || profile.Memory <= 0 || profile.RootSize <= 0 {
+			return fmt.Errorf("invalid profile %s: cpu, memory and root_size must be positive", name)
+		}
+	}
+	if _, ok := profiles[selected]; selected != "" && !ok {
+		return fmt.Errorf("invalid profile: %s, must be one of the configured profiles", selected)
+	}
+	return nil
+}
+
 // ValidateConfig performs all validations on the provided configuration
 func ValidateConfig(cfg types.Config) error {
 	if err := validateMnemonic(cfg.Mnemonic); err != nil {
@@ -106,6 +165,18 @@ func ValidateConfig(cfg types.Config) error {
 	if err := validateInfluxConfig(cfg.Influx); err != nil {
 		return err
 	}
+	if err := validateTTL(cfg.TTL); err != nil {
+		return err
+	}
+	if err := validateCampaigns(cfg.Campaigns); err != nil {
+		return err
+	}
+	if err := validateQuarantine(cfg.Quarantine); err != nil {
+		return err
+	}
+	if err := validateProfiles(cfg.Profile, cfg.Profiles); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -0,0 +1,146 @@
+package report
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	spawner "github.com/threefoldtech/guardians_healthchecker/spawner/pkg/spawner"
+)
+
+// Supported report formats
+const (
+	JSONFormat  = "json"
+	JUnitFormat = "junit"
+)
+
+// FormatFromPath guesses the report format from the file extension, defaults to json
+func FormatFromPath(path string) string {
+	if filepath.Ext(path) == ".xml" {
+		return JUnitFormat
+	}
+	return JSONFormat
+}
+
+// WriteFile writes the spawn result report to the given path in the given format
+func WriteFile(path, format string, result spawner.SpawnResult) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create report file '%s': %w", path, err)
+	}
+	defer file.Close()
+
+	switch format {
+	case JSONFormat:
+		err = WriteJSON(file, result)
+	case JUnitFormat:
+		err = WriteJUnit(file, result)
+	default:
+		err = fmt.Errorf("unsupported report format '%s', should be %s or %s", format, JSONFormat, JUnitFormat)
+	}
+	if err != nil {
+		return err
+	}
+
+	return file.Close()
+}
+
+// WriteJSON writes the spawn result as an indented JSON document
+func WriteJSON(w io.Writer, result spawner.SpawnResult) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(result)
+}
+
+// WriteJUnit writes the spawn result as a JUnit XML document, each farm is a test suite
+// and each node is a test case
+func WriteJUnit(w io.Writer, result spawner.SpawnResult) error {
+	suites := junitTestSuites{Name: fmt.Sprintf("spawner run %s", result.RunID), Time: result.Duration.Seconds()}
+
+	for _, farm := range result.Farms {
+		suite := junitTestSuite{Name: fmt.Sprintf("farm %d", farm.Farm)}
+
+		if len(farm.Nodes) == 0 && farm.Error != "" {
+			suite.Errors++
+			suite.TestCases = append(suite.TestCases, junitTestCase{
+				Name:      "node selection",
+				ClassName: suite.Name,
+				Error:     &junitFailure{Message: farm.Error, Type: string(farm.ErrorClass), Body: farm.Error},
+			})
+		}
+
+		for _, node := range farm.Nodes {
+			testCase := junitTestCase{
+				Name:      fmt.Sprintf("node %d", node.Node),
+				ClassName: suite.Name,
+				Time:      node.Duration.Seconds(),
+			}
+			if !node.Succeeded() {
+				suite.Failures++
+				testCase.Failure = &junitFailure{Message: "deployment failed", Type: string(node.ErrorClass), Body: node.Error}
+			}
+
+			suite.Time += node.Duration.Seconds()
+			suite.TestCases = append(suite.TestCases, testCase)
+		}
+
+		suite.Tests = len(suite.TestCases)
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Errors += suite.Errors
+		suites.Suites = append(suites.Suites, suite)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(suites); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// junitTestSuites is the root element of a JUnit XML report
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Time     float64          `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+// junitTestSuite holds the test cases of a single farm
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Time      float64         `xml:"time,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+// junitTestCase holds the deployment outcome of a single node
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      float64       `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Error     *junitFailure `xml:"error,omitempty"`
+}
+
+// junitFailure holds the error text of a failed test case
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Body    string `xml:",chardata"`
+}
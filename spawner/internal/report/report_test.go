@@ -0,0 +1,51 @@
+package report
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+	"time"
+
+	spawner "github.com/threefoldtech/guardians_healthchecker/spawner/pkg/spawner"
+	"gotest.tools/assert"
+)
+
+func TestWriteJUnit(t *testing.T) {
+	result := spawner.SpawnResult{
+		Farms: []spawner.FarmResult{
+			{
+				Farm: 1,
+				Nodes: []spawner.NodeResult{
+					{Node: 11, VMContractID: 100, NetworkContractID: 99, Attempts: 1, Duration: 2 * time.Second},
+					{Node: 12, Attempts: 5, Duration: 3 * time.Second, Error: "error waiting deployment on node 12"},
+				},
+			},
+			{Farm: 2, Error: "failed to get nodes"},
+		},
+		Duration: 5 * time.Second,
+	}
+
+	var buf bytes.Buffer
+	err := WriteJUnit(&buf, result)
+	assert.NilError(t, err)
+
+	var suites junitTestSuites
+	err = xml.Unmarshal(buf.Bytes(), &suites)
+	assert.NilError(t, err)
+
+	assert.Equal(t, suites.Tests, 3)
+	assert.Equal(t, suites.Failures, 1)
+	assert.Equal(t, suites.Errors, 1)
+	assert.Equal(t, len(suites.Suites), 2)
+
+	farm := suites.Suites[0]
+	assert.Equal(t, farm.Name, "farm 1")
+	assert.Assert(t, farm.TestCases[0].Failure == nil)
+	assert.Equal(t, farm.TestCases[1].Failure.Body, "error waiting deployment on node 12")
+}
+
+func TestFormatFromPath(t *testing.T) {
+	assert.Equal(t, FormatFromPath("report.xml"), JUnitFormat)
+	assert.Equal(t, FormatFromPath("report.json"), JSONFormat)
+	assert.Equal(t, FormatFromPath("report"), JSONFormat)
+}
@@ -0,0 +1,270 @@
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/threefoldtech/guardians_healthchecker/spawner/internal/analysis"
+	"github.com/threefoldtech/guardians_healthchecker/spawner/internal/history"
+	"github.com/threefoldtech/guardians_healthchecker/spawner/internal/influx"
+	"github.com/threefoldtech/guardians_healthchecker/spawner/internal/results"
+)
+
+// Supported scorecard formats
+const (
+	MarkdownFormat = "markdown"
+	HTMLFormat     = "html"
+)
+
+// Weights of the components of the health score of a farm, they add up to 1
+const (
+	deploymentWeight = 0.3
+	reportWeight     = 0.3
+	benchmarkWeight  = 0.2
+	anomalyWeight    = 0.2
+)
+
+// Scorecard holds the health of every farm of a run
+type Scorecard struct {
+	RunID     string          `json:"run_id"`
+	StartedAt time.Time       `json:"started_at"`
+	Farms     []FarmScorecard `json:"farms"`
+}
+
+// FarmScorecard holds the health of a farm in a run, the rates and the health are between 0 and 1
+type FarmScorecard struct {
+	Farm           uint64             `json:"farm"`
+	Nodes          int                `json:"nodes"`
+	Deployed       int                `json:"deployed"`
+	Reported       int                `json:"reported"`
+	DeploymentRate float64            `json:"deployment_rate"`
+	ReportRate     float64            `json:"report_rate"`
+	Medians        map[string]float64 `json:"medians"`
+	// BenchmarkRate is the median score of the farm relative to the median score of the run, averaged over the categories
+	BenchmarkRate float64            `json:"benchmark_rate"`
+	Anomalies     []analysis.Anomaly `json:"anomalies,omitempty"`
+	Health        float64            `json:"health"`
+	Grade         string             `json:"grade"`
+	Error         string             `json:"error,omitempty"`
+}
+
+// NewScorecard rates every farm of the run from its deployment outcomes, the benchmark scores of its nodes and their anomalies
+func NewScorecard(run history.Run, nodes []results.NodeScores, anomalies []analysis.Anomaly) Scorecard {
+	scorecard := Scorecard{RunID: run.ID, StartedAt: run.StartedAt}
+	if run.Spawn == nil {
+		return scorecard
+	}
+
+	runMedians := medians(nodes)
+	for _, farm := range run.Spawn.Farms {
+		card := FarmScorecard{Farm: farm.Farm, Error: farm.Error}
+
+		var farmNodes []results.NodeScores
+		for _, node := range nodes {
+			if node.Farm != farm.Farm {
+				continue
+			}
+			farmNodes = append(farmNodes, node)
+
+			card.Nodes++
+			if node.Deployed {
+				card.Deployed++
+			}
+			if node.Reported() {
+				card.Reported++
+			}
+		}
+		card.Medians = medians(farmNodes)
+
+		anomalous := make(map[uint32]bool)
+		for _, anomaly := range anomalies {
+			if anomaly.Farm == farm.Farm {
+				card.Anomalies = append(card.Anomalies, anomaly)
+				anomalous[anomaly.Node] = true
+			}
+		}
+
+		anomalyRate := 0.0
+		if card.Nodes != 0 {
+			card.DeploymentRate = float64(card.Deployed) / float64(card.Nodes)
+		}
+		if card.Deployed != 0 {
+			card.ReportRate = float64(card.Reported) / float64(card.Deployed)
+		}
+		if card.Reported != 0 {
+			anomalyRate = 1 - float64(len(anomalous))/float64(card.Reported)
+		}
+		for category, median := range card.Medians {
+			if runMedians[category] > 0 {
+				card.BenchmarkRate += min(median/runMedians[category], 1) / float64(len(card.Medians))
+			}
+		}
+
+		card.Health = deploymentWeight*card.DeploymentRate + reportWeight*card.ReportRate +
+			benchmarkWeight*card.BenchmarkRate + anomalyWeight*anomalyRate
+		card.Grade = grade(card.Health)
+		scorecard.Farms = append(scorecard.Farms, card)
+	}
+
+	return scorecard
+}
+
+// grade returns the letter grade of a health score
+func grade(health float64) string {
+	switch {
+	case health >= 0.9:
+		return "A"
+	case health >= 0.8:
+		return "B"
+	case health >= 0.7:
+		return "C"
+	case health >= 0.6:
+		return "D"
+	default:
+		return "F"
+	}
+}
+
+// medians returns the median score of the nodes in every category
+func medians(nodes []results.NodeScores) map[string]float64 {
+	values := make(map[string][]float64)
+	for _, node := range nodes {
+		for category, score := range node.Scores {
+			values[category] = append(values[category], score)
+		}
+	}
+
+	medians := make(map[string]float64, len(values))
+	for category, scores := range values {
+		slices.Sort(scores)
+		mid := len(scores) / 2
+		if len(scores)%2 == 0 {
+			medians[category] = (scores[mid-1] + scores[mid]) / 2
+		} else {
+			medians[category] = scores[mid]
+		}
+	}
+
+	return medians
+}
+
+// WriteScorecard writes the scorecard in the given format
+func WriteScorecard(w io.Writer, format string, scorecard Scorecard) error {
+	switch format {
+	case MarkdownFormat:
+		return WriteMarkdown(w, scorecard)
+	case HTMLFormat:
+		return WriteHTML(w, scorecard)
+	default:
+		return fmt.Errorf("unsupported scorecard format '%s', should be %s or %s", format, MarkdownFormat, HTMLFormat)
+	}
+}
+
+// WriteMarkdown writes the scorecard as a Markdown document
+func WriteMarkdown(w io.Writer, scorecard Scorecard) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Farm Health Scorecard\n\nRun `%s` started at %s.\n\n", scorecard.RunID, scorecard.StartedAt.UTC().Format(time.RFC3339))
+
+	b.WriteString("| Farm | Grade | Health | Deployed | Reported |")
+	for _, category := range influx.Categories {
+		fmt.Fprintf(&b, " %s |", strings.ToUpper(category[:1])+category[1:])
+	}
+	b.WriteString(" Anomalies |\n|---|---|---|---|---|")
+	for range influx.Categories {
+		b.WriteString("---|")
+	}
+	b.WriteString("---|\n")
+
+	for _, farm := range scorecard.Farms {
+		fmt.Fprintf(&b, "| %d | %s | %s | %s | %s |", farm.Farm, farm.Grade, percent(farm.Health),
+			ratio(farm.Deployed, farm.Nodes), ratio(farm.Reported, farm.Deployed))
+		for _, category := range influx.Categories {
+			fmt.Fprintf(&b, " %s |", median(farm, category))
+		}
+		fmt.Fprintf(&b, " %d |\n", len(farm.Anomalies))
+	}
+
+	for _, farm := range scorecard.Farms {
+		if farm.Error == "" && len(farm.Anomalies) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&b, "\n## Farm %d\n\n", farm.Farm)
+		if farm.Error != "" {
+			fmt.Fprintf(&b, "Error: %s\n\n", farm.Error)
+		}
+		for _, anomaly := range farm.Anomalies {
+			fmt.Fprintf(&b, "- %s\n", anomaly.Explanation)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteHTML writes the scorecard as a standalone HTML document
+func WriteHTML(w io.Writer, scorecard Scorecard) error {
+	return scorecardTemplate.Execute(w, scorecard)
+}
+
+// percent formats a rate between 0 and 1 as a percentage
+func percent(rate float64) string {
+	return strconv.FormatFloat(rate*100, 'f', 0, 64) + "%"
+}
+
+// ratio formats a part of a total along with its percentage
+func ratio(part, total int) string {
+	if total == 0 {
+		return "0/0"
+	}
+	return fmt.Sprintf("%d/%d (%s)", part, total, percent(float64(part)/float64(total)))
+}
+
+// median formats the median score of the farm in a category
+func median(farm FarmScorecard, category string) string {
+	score, ok := farm.Medians[category]
+	if !ok {
+		return "-"
+	}
+	return strconv.FormatFloat(score, 'f', 2, 64)
+}
+
+var scorecardTemplate = template.Must(template.New("scorecard").Funcs(template.FuncMap{
+	"percent":    percent,
+	"ratio":      ratio,
+	"median":     median,
+	"categories": func() []string { return influx.Categories },
+	"time":       func(t time.Time) string { return t.UTC().Format(time.RFC3339) },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Farm Health Scorecard {{.RunID}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 0.4em 0.8em; text-align: right; }
+.grade-A { background: #c8e6c9; } .grade-B { background: #dcedc8; } .grade-C { background: #fff9c4; }
+.grade-D { background: #ffe0b2; } .grade-F { background: #ffcdd2; }
+</style>
+</head>
+<body>
+<h1>Farm Health Scorecard</h1>
+<p>Run <code>{{.RunID}}</code> started at {{time .StartedAt}}.</p>
+<table>
+<tr><th>Farm</th><th>Grade</th><th>Health</th><th>Deployed</th><th>Reported</th>{{range categories}}<th>{{.}}</th>{{end}}<th>Anomalies</th></tr>
+{{range $farm := .Farms}}<tr class="grade-{{.Grade}}"><td>{{.Farm}}</td><td>{{.Grade}}</td><td>{{percent .Health}}</td><td>{{ratio .Deployed .Nodes}}</td><td>{{ratio .Reported .Deployed}}</td>{{range categories}}<td>{{median $farm .}}</td>{{end}}<td>{{len .Anomalies}}</td></tr>
+{{end}}</table>
+{{range .Farms}}{{if or .Error .Anomalies}}<h2>Farm {{.Farm}}</h2>
+{{if .Error}}<p>Error: {{.Error}}</p>
+{{end}}{{if .Anomalies}}<ul>
+{{range .Anomalies}}<li>{{.Explanation}}</li>
+{{end}}</ul>
+{{end}}{{end}}{{end}}</body>
+</html>
+`))
@@ -0,0 +1,70 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/threefoldtech/guardians_healthchecker/spawner/internal/analysis"
+	"github.com/threefoldtech/guardians_healthchecker/spawner/internal/history"
+	"github.com/threefoldtech/guardians_healthchecker/spawner/internal/results"
+	spawner "github.com/threefoldtech/guardians_healthchecker/spawner/pkg/spawner"
+	"gotest.tools/assert"
+)
+
+func TestNewScorecard(t *testing.T) {
+	run := history.Run{
+		ID:        "run-1",
+		StartedAt: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+		Spawn: &spawner.SpawnResult{Farms: []spawner.FarmResult{
+			{Farm: 1, Nodes: []spawner.NodeResult{{Node: 11}, {Node: 12}}},
+			{Farm: 2, Nodes: []spawner.NodeResult{{Node: 21}, {Node: 22}}},
+			{Farm: 3, Error: "no eligible nodes"},
+		}},
+	}
+	nodes := []results.NodeScores{
+		{Farm: 1, Node: 11, Deployed: true, Scores: map[string]float64{"cpu": 100}},
+		{Farm: 1, Node: 12, Deployed: true, Scores: map[string]float64{"cpu": 100}},
+		{Farm: 2, Node: 21, Deployed: true, Scores: map[string]float64{"cpu": 50}},
+		{Farm: 2, Node: 22, Deployed: false},
+	}
+	anomalies := []analysis.Anomaly{{Farm: 2, Node: 21, Category: "cpu", Explanation: "node 21 cpu 2.0x below farm median"}}
+
+	scorecard := NewScorecard(run, nodes, anomalies)
+	assert.Equal(t, len(scorecard.Farms), 3)
+
+	healthy := scorecard.Farms[0]
+	assert.Equal(t, healthy.DeploymentRate, 1.0)
+	assert.Equal(t, healthy.ReportRate, 1.0)
+	assert.Equal(t, healthy.BenchmarkRate, 1.0)
+	assert.Equal(t, healthy.Grade, "A")
+
+	degraded := scorecard.Farms[1]
+	assert.Equal(t, degraded.DeploymentRate, 0.5)
+	assert.Equal(t, degraded.ReportRate, 1.0)
+	assert.Equal(t, degraded.BenchmarkRate, 0.5)
+	assert.Equal(t, len(degraded.Anomalies), 1)
+	assert.Equal(t, degraded.Grade, "F")
+
+	failed := scorecard.Farms[2]
+	assert.Equal(t, failed.Health, 0.0)
+	assert.Equal(t, failed.Grade, "F")
+
+	t.Run("markdown", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.NilError(t, WriteScorecard(&buf, MarkdownFormat, scorecard))
+		assert.Assert(t, strings.Contains(buf.String(), "| 1 | A | 100% | 2/2 (100%) | 2/2 (100%) | 100.00 | - | - | - | 0 |"))
+		assert.Assert(t, strings.Contains(buf.String(), "- node 21 cpu 2.0x below farm median"))
+		assert.Assert(t, strings.Contains(buf.String(), "Error: no eligible nodes"))
+	})
+	t.Run("html", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.NilError(t, WriteScorecard(&buf, HTMLFormat, scorecard))
+		assert.Assert(t, strings.Contains(buf.String(), `<tr class="grade-A"><td>1</td>`))
+		assert.Assert(t, strings.Contains(buf.String(), "<li>node 21 cpu 2.0x below farm median</li>"))
+	})
+	t.Run("unsupported format", func(t *testing.T) {
+		assert.ErrorContains(t, WriteScorecard(&bytes.Buffer{}, "pdf", scorecard), "unsupported scorecard format")
+	})
+}
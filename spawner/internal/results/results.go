@@ -0,0 +1,184 @@
+package results
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/threefoldtech/guardians_healthchecker/spawner/internal/history"
+	"github.com/threefoldtech/guardians_healthchecker/spawner/internal/influx"
+)
+
+// NodeScores holds the benchmark scores reported by a node of a run
+type NodeScores struct {
+	Farm     uint64             `json:"farm"`
+	Node     uint32             `json:"node"`
+	Deployed bool               `json:"deployed"`
+	Scores   map[string]float64 `json:"scores"`
+}
+
+// Reported reports whether the node wrote any benchmark score
+func (s NodeScores) Reported() bool {
+	return len(s.Scores) != 0
+}
+
+// SilentNode is a node whose VM was deployed by a run but never wrote any benchmark point
+type SilentNode struct {
+	Farm         uint64 `json:"farm"`
+	Node         uint32 `json:"node"`
+	VMContractID uint64 `json:"vm_contract_id"`
+}
+
+// Window returns the time range the benchmark results of the run were written in,
+// the range of a run whose VMs are not destroyed yet ends now
+func Window(run history.Run, now time.Time) (start, stop time.Time) {
+	stop = now
+	if run.Destroy != nil && !run.FinishedAt.IsZero() {
+		stop = run.FinishedAt
+	}
+
+	return run.StartedAt, stop
+}
+
+// Collect queries the benchmark scores written during the run and joins them with the nodes of the run
+func Collect(ctx context.Context, client *influx.Client, run history.Run) ([]NodeScores, error) {
+	start, stop := Window(run, time.Now())
+	scores, err := client.QueryScores(ctx, start, stop)
+	if err != nil {
+		return nil, err
+	}
+
+	return Join(run, scores), nil
+}
+
+// Join returns the scores of every node of the run ordered by farm and node, nodes which
+// reported scores without being deployed by the run are ignored
+func Join(run history.Run, scores map[uint32]map[string]float64) []NodeScores {
+	if run.Spawn == nil {
+		return nil
+	}
+
+	var nodes []NodeScores
+	for _, farm := range run.Spawn.Farms {
+		for _, node := range farm.Nodes {
+			nodes = append(nodes, NodeScores{
+				Farm:     farm.Farm,
+				Node:     node.Node,
+				Deployed: node.Succeeded(),
+				Scores:   scores[node.Node],
+			})
+		}
+	}
+
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].Farm != nodes[j].Farm {
+			return nodes[i].Farm < nodes[j].Farm
+		}
+		return nodes[i].Node < nodes[j].Node
+	})
+
+	return nodes
+}
+
+// DeployedAt returns when the VMs of the run finished deploying
+func DeployedAt(run history.Run) time.Time {
+	if run.Spawn == nil {
+		return run.StartedAt
+	}
+	return run.StartedAt.Add(run.Spawn.Duration)
+}
+
+// FindSilent queries the nodes which wrote benchmark points during the run and returns the deployed nodes which did not
+func FindSilent(ctx context.Context, client *influx.Client, run history.Run) ([]SilentNode, error) {
+	start, stop := Window(run, time.Now())
+	reporting, err := client.QueryReportingNodes(ctx, start, stop)
+	if err != nil {
+		return nil, err
+	}
+
+	return Silent(run, reporting), nil
+}
+
+// Silent returns the nodes successfully deployed by the run which are not reporting, ordered by farm and node
+func Silent(run history.Run, reporting map[uint32]bool) []SilentNode {
+	if run.Spawn == nil {
+		return nil
+	}
+
+	var silent []SilentNode
+	for _, farm := range run.Spawn.Farms {
+		for _, node := range farm.Nodes {
+			if node.Succeeded() && !reporting[node.Node] {
+				silent = append(silent, SilentNode{Farm: farm.Farm, Node: node.Node, VMContractID: node.VMContractID})
+			}
+		}
+	}
+
+	sort.Slice(silent, func(i, j int) bool {
+		if silent[i].Farm != silent[j].Farm {
+			return silent[i].Farm < silent[j].Farm
+		}
+		return silent[i].Node < silent[j].Node
+	})
+
+	return silent
+}
+
+// Completed returns the nodes which reported the score of every benchmark category, as the benchmark
+// VMs write no marker once they are done
+func Completed(scores map[uint32]map[string]float64) map[uint32]bool {
+	completed := make(map[uint32]bool)
+	for node, categories := range scores {
+		completed[node] = !slices.ContainsFunc(influx.Categories, func(category string) bool {
+			_, ok := categories[category]
+			return !ok
+		})
+	}
+
+	return completed
+}
+
+// CompletionChecker returns the nodes which completed their benchmarks since the given time
+type CompletionChecker func(ctx context.Context, since time.Time) (map[uint32]bool, error)
+
+// WaitCompleted polls check every interval until all the nodes completed their benchmarks or ctx is done,
+// done is called with the nodes which completed since the previous poll. The nodes which did not complete are
+// returned with the context error when ctx is done first.
+func WaitCompleted(ctx context.Context, check CompletionChecker, nodes []uint32, since time.Time, interval time.Duration, done func(nodes []uint32)) ([]uint32, error) {
+	pending := slices.Clone(nodes)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for len(pending) != 0 {
+		completed, err := check(ctx, since)
+		if err != nil && ctx.Err() == nil {
+			log.Warn().Err(err).Msg("failed to check benchmark completion")
+		}
+
+		var finished []uint32
+		pending = slices.DeleteFunc(pending, func(node uint32) bool {
+			if completed[node] {
+				finished = append(finished, node)
+				return true
+			}
+			return false
+		})
+		if len(finished) != 0 {
+			log.Info().Msgf("%d benchmarks completed, %d pending", len(finished), len(pending))
+			done(finished)
+		}
+		if len(pending) == 0 {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return pending, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,112 @@
+package results
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/threefoldtech/guardians_healthchecker/spawner/internal/history"
+	spawner "github.com/threefoldtech/guardians_healthchecker/spawner/pkg/spawner"
+	"gotest.tools/assert"
+)
+
+func TestJoin(t *testing.T) {
+	run := history.Run{
+		ID: "run-1",
+		Spawn: &spawner.SpawnResult{Farms: []spawner.FarmResult{
+			{Farm: 2, Nodes: []spawner.NodeResult{{Node: 21, VMContractID: 210}}},
+			{Farm: 1, Nodes: []spawner.NodeResult{
+				{Node: 12, VMContractID: 120},
+				{Node: 11, Error: "out of capacity"},
+			}},
+		}},
+	}
+	scores := map[uint32]map[string]float64{
+		12: {"cpu": 1000},
+		21: {"cpu": 900, "disk": 500},
+		99: {"cpu": 100},
+	}
+
+	nodes := Join(run, scores)
+	assert.DeepEqual(t, nodes, []NodeScores{
+		{Farm: 1, Node: 11},
+		{Farm: 1, Node: 12, Deployed: true, Scores: map[string]float64{"cpu": 1000}},
+		{Farm: 2, Node: 21, Deployed: true, Scores: map[string]float64{"cpu": 900, "disk": 500}},
+	})
+	assert.Assert(t, !nodes[0].Reported())
+	assert.Assert(t, nodes[1].Reported())
+}
+
+func TestWindow(t *testing.T) {
+	now := time.Now()
+	run := history.Run{StartedAt: now.Add(-2 * time.Hour), FinishedAt: now.Add(-time.Hour)}
+
+	start, stop := Window(run, now)
+	assert.Equal(t, start, run.StartedAt)
+	assert.Equal(t, stop, now)
+
+	run.Destroy = &spawner.DestroyResult{}
+	_, stop = Window(run, now)
+	assert.Equal(t, stop, run.FinishedAt)
+}
+
+func TestSilent(t *testing.T) {
+	run := history.Run{
+		ID: "run-1",
+		Spawn: &spawner.SpawnResult{Farms: []spawner.FarmResult{
+			{Farm: 1, Nodes: []spawner.NodeResult{
+				{Node: 12, VMContractID: 120},
+				{Node: 11, VMContractID: 110},
+				{Node: 13, Error: "out of capacity"},
+			}},
+			{Farm: 2, Nodes: []spawner.NodeResult{{Node: 21, VMContractID: 210}}},
+		}},
+	}
+
+	silent := Silent(run, map[uint32]bool{12: true, 99: true})
+	assert.DeepEqual(t, silent, []SilentNode{
+		{Farm: 1, Node: 11, VMContractID: 110},
+		{Farm: 2, Node: 21, VMContractID: 210},
+	})
+}
+
+func TestCompleted(t *testing.T) {
+	completed := Completed(map[uint32]map[string]float64{
+		11: {"cpu": 1200, "memory": 900, "disk": 830, "network": 450},
+		12: {"cpu": 990, "disk": 700},
+	})
+	assert.DeepEqual(t, completed, map[uint32]bool{11: true, 12: false})
+}
+
+func TestWaitCompleted(t *testing.T) {
+	polls := []map[uint32]bool{
+		{11: true},
+		{11: true, 12: true},
+	}
+	check := func(ctx context.Context, since time.Time) (map[uint32]bool, error) {
+		completed := polls[0]
+		if len(polls) > 1 {
+			polls = polls[1:]
+		}
+		return completed, nil
+	}
+
+	t.Run("all completed", func(t *testing.T) {
+		var done [][]uint32
+		pending, err := WaitCompleted(context.Background(), check, []uint32{11, 12}, time.Now(), time.Millisecond, func(nodes []uint32) {
+			done = append(done, nodes)
+		})
+		assert.NilError(t, err)
+		assert.Equal(t, len(pending), 0)
+		assert.DeepEqual(t, done, [][]uint32{{11}, {12}})
+	})
+	t.Run("timeout", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		pending, err := WaitCompleted(ctx, check, []uint32{11, 13}, time.Now(), time.Millisecond, func(nodes []uint32) {})
+		assert.Assert(t, errors.Is(err, context.DeadlineExceeded))
+		assert.DeepEqual(t, pending, []uint32{13})
+	})
+}
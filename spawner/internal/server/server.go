@@ -0,0 +1,390 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/threefoldtech/guardians_healthchecker/spawner/internal/history"
+	"github.com/threefoldtech/guardians_healthchecker/spawner/internal/influx"
+	"github.com/threefoldtech/guardians_healthchecker/spawner/internal/metrics"
+	"github.com/threefoldtech/guardians_healthchecker/spawner/internal/parser"
+	spawner "github.com/threefoldtech/guardians_healthchecker/spawner/pkg/spawner"
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-client/deployer"
+)
+
+// Represents the server timeouts
+const (
+	cleanupTimeout  = 10 * time.Minute
+	shutdownTimeout = 30 * time.Second
+)
+
+// maxRequestSize bounds the body of the requests starting a run
+const maxRequestSize = 1 << 20
+
+// Server exposes spawning, listing and destroying the benchmark VMs over a REST API
+type Server struct {
+	cfg            spawner.Config
+	tfPluginClient deployer.TFPluginClient
+	history        *history.Store
+	metrics        *metrics.Metrics
+	influx         *influx.Client
+	token          string
+
+	// ctx is the parent context of the runs, it is done when the server stops
+	ctx  context.Context
+	runs sync.WaitGroup
+
+	mu sync.Mutex
+	// active maps the farms of the runs in progress to their run ID, a farm is deployed by a single run at a time
+	active  map[uint64]string
+	cancels map[string]context.CancelFunc
+}
+
+// runRequest is the body of a request starting a run, unset fields default to the configuration file
+type runRequest struct {
+	Farms              []uint64 `json:"farms"`
+	DeploymentStrategy *float64 `json:"deployment_strategy,omitempty"`
+	FailureStrategy    string   `json:"failure_strategy,omitempty"`
+	// Profile selects the resources of the VMs out of the configured profiles
+	Profile string `json:"profile,omitempty"`
+}
+
+// errorResponse is the body of a failed request
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// New returns a server running the requests with cfg and recording the runs in store and in m,
+// every request except the metrics must carry token as a bearer token
+func New(cfg spawner.Config, tfPluginClient deployer.TFPluginClient, store *history.Store, m *metrics.Metrics, token string) *Server {
+	return &Server{
+		cfg:            cfg,
+		tfPluginClient: tfPluginClient,
+		history:        store,
+		metrics:        m,
+		influx:         influx.New(cfg.Influx),
+		token:          token,
+		ctx:            context.Background(),
+		active:         make(map[uint64]string),
+		cancels:        make(map[string]context.CancelFunc),
+	}
+}
+
+// Handler returns the HTTP handler of the API
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /runs", s.createRun)
+	mux.HandleFunc("GET /runs", s.listRuns)
+	mux.HandleFunc("GET /runs/{id}", s.getRun)
+	mux.HandleFunc("DELETE /runs/{id}", s.deleteRun)
+	mux.HandleFunc("GET /inventory", s.inventory)
+
+	// metrics are scraped without the token
+	root := http.NewServeMux()
+	root.Handle("GET /metrics", s.metrics.Handler())
+	root.Handle("/", s.authenticate(mux))
+
+	return root
+}
+
+// Run serves the API on addr until ctx is done, the runs in progress are then rolled back
+func (s *Server) Run(ctx context.Context, addr string) error {
+	s.ctx = ctx
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		log.Info().Str("Address", addr).Msg("serving API")
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Info().Msg("stopping server")
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shutdownTimeout)
+	defer cancel()
+
+	err := srv.Shutdown(shutdownCtx)
+	s.runs.Wait()
+
+	return err
+}
+
+// authenticate rejects the requests without the server bearer token
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			writeError(w, http.StatusUnauthorized, errors.New("invalid or missing bearer token"))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// createRun starts spawning the VMs of a new run in the background
+func (s *Server) createRun(w http.ResponseWriter, r *http.Request) {
+	var request runRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&request); err != nil {
+		status := http.StatusBadRequest
+		var sizeErr *http.MaxBytesError
+		if errors.As(err, &sizeErr) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		writeError(w, status, fmt.Errorf("invalid run request: %w", err))
+		return
+	}
+	if len(request.Farms) == 0 {
+		writeError(w, http.StatusBadRequest, errors.New("invalid run request: farms cannot be empty"))
+		return
+	}
+
+	cfg := s.cfg
+	cfg.Farms = request.Farms
+	if request.DeploymentStrategy != nil {
+		cfg.DeploymentStrategy = *request.DeploymentStrategy
+	}
+	if request.FailureStrategy != "" {
+		cfg.FailureStrategy = request.FailureStrategy
+	}
+	if request.Profile != "" {
+		if _, ok := cfg.Profiles[request.Profile]; !ok {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid run request: unknown profile %s", request.Profile))
+			return
+		}
+		cfg.Profile = request.Profile
+	}
+	if err := parser.ValidateConfig(cfg); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid run request: %w", err))
+		return
+	}
+
+	run := history.Run{
+		ID:        spawner.NewRunID(),
+		Farms:     cfg.Farms,
+		Status:    history.RunningStatus,
+		StartedAt: time.Now(),
+		Config:    history.NewConfigSnapshot(cfg),
+	}
+
+	ctx, err := s.acquire(run)
+	if err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	if err := s.history.Save(run); err != nil {
+		s.release(run)
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	s.runs.Add(1)
+	go s.spawn(ctx, cfg, run)
+
+	writeJSON(w, http.StatusAccepted, run)
+}
+
+// listRuns returns all the recorded runs
+func (s *Server) listRuns(w http.ResponseWriter, r *http.Request) {
+	runs, err := s.history.List()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, runs)
+}
+
+// getRun returns the status and results of a run
+func (s *Server) getRun(w http.ResponseWriter, r *http.Request) {
+	run, err := s.history.Get(r.PathValue("id"))
+	if errors.Is(err, history.ErrRunNotFound) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, run)
+}
+
+// deleteRun destroys the VMs of a run, a run in progress is stopped and rolled back
+func (s *Server) deleteRun(w http.ResponseWriter, r *http.Request) {
+	run, err := s.history.Get(r.PathValue("id"))
+	if errors.Is(err, history.ErrRunNotFound) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	s.mu.Lock()
+	cancel, running := s.cancels[run.ID]
+	s.mu.Unlock()
+	if running {
+		cancel()
+		writeJSON(w, http.StatusAccepted, run)
+		return
+	}
+
+	ctx, err := s.acquire(run)
+	if err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	defer s.release(run)
+
+	cfg := s.cfg
+	cfg.Farms = run.Farms
+	result, err := spawner.Destroy(ctx, cfg, s.tfPluginClient, run.DestroyOptions())
+	run.Destroy = &result
+	s.metrics.ObserveDestroy(result)
+	run.Status = history.DestroyedStatus
+	if err != nil {
+		run.Status = history.FailedStatus
+		run.Error = err.Error()
+	}
+	saveErr := s.history.Update(run.ID, func(recorded *history.Run) error {
+		recorded.Destroy, recorded.Status, recorded.Error = run.Destroy, run.Status, run.Error
+		return nil
+	})
+	if saveErr != nil {
+		log.Error().Err(saveErr).Str("Run", run.ID).Msg("failed to save run")
+	}
+
+	if err != nil {
+		writeError(w, http.StatusBadGateway, fmt.Errorf("failed to destroy run %s: %w", run.ID, err))
+		return
+	}
+	writeJSON(w, http.StatusOK, run)
+}
+
+// inventory returns the benchmark deployments on the configured farms, the deployments on unreachable
+// nodes are listed with their error and the request only fails if nothing could be listed
+func (s *Server) inventory(w http.ResponseWriter, r *http.Request) {
+	vms, err := spawner.Inventory(r.Context(), s.cfg, s.tfPluginClient)
+	if err != nil && len(vms) == 0 {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	if err != nil {
+		log.Warn().Err(err).Msg("some deployments could not be fetched")
+	}
+	if vms == nil {
+		vms = []spawner.VMInfo{}
+	}
+
+	writeJSON(w, http.StatusOK, vms)
+}
+
+// spawn deploys the VMs of a run and records the outcome, the run is rolled back if it is stopped
+func (s *Server) spawn(ctx context.Context, cfg spawner.Config, run history.Run) {
+	defer s.runs.Done()
+	defer s.release(run)
+
+	result, err := spawner.Spawn(ctx, cfg, s.tfPluginClient, spawner.SpawnOptions{RunID: run.ID, Exclude: s.history.ExcludedNodes()})
+	run.SetSpawn(result)
+	s.metrics.ObserveSpawn(result)
+	s.history.TrackOutcomes(ctx, result, cfg.Quarantine)
+	if err := s.influx.WriteSpawn(context.WithoutCancel(ctx), result); err != nil {
+		log.Warn().Err(err).Str("Run", run.ID).Msg("failed to write deployment telemetry")
+	}
+	run.Status = history.CompletedStatus
+	if err != nil {
+		run.Status = history.FailedStatus
+		run.Error = err.Error()
+	}
+
+	if ctx.Err() != nil {
+		destroyCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cleanupTimeout)
+		defer cancel()
+
+		destroyResult, destroyErr := spawner.Destroy(destroyCtx, cfg, s.tfPluginClient, run.DestroyOptions())
+		run.Destroy = &destroyResult
+		s.metrics.ObserveDestroy(destroyResult)
+		run.Status = history.RolledBackStatus
+		if destroyErr != nil {
+			run.Error = destroyErr.Error()
+		}
+	}
+
+	run.FinishedAt = time.Now()
+	if err := s.history.Save(run); err != nil {
+		log.Error().Err(err).Str("Run", run.ID).Msg("failed to save run")
+	}
+	log.Info().Str("Run", run.ID).Str("Status", string(run.Status)).Msg("run finished")
+}
+
+// acquire marks the farms of the run as busy and returns the context of the run,
+// it fails if any of the farms is used by another run
+func (s *Server) acquire(run history.Run) (context.Context, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, farm := range run.Farms {
+		if other, ok := s.active[farm]; ok {
+			return nil, fmt.Errorf("farm %d is busy with run %s", farm, other)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	for _, farm := range run.Farms {
+		s.active[farm] = run.ID
+	}
+	s.cancels[run.ID] = cancel
+
+	return ctx, nil
+}
+
+// release frees the farms of the run
+func (s *Server) release(run history.Run) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, farm := range run.Farms {
+		if s.active[farm] == run.ID {
+			delete(s.active, farm)
+		}
+	}
+	if cancel, ok := s.cancels[run.ID]; ok {
+		cancel()
+		delete(s.cancels, run.ID)
+	}
+}
+
+// writeJSON writes v as the JSON body of the response
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Error().Err(err).Msg("failed to write response")
+	}
+}
+
+// writeError writes err as the JSON body of the response
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
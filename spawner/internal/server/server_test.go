@@ -0,0 +1,97 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/threefoldtech/guardians_healthchecker/spawner/internal/history"
+	"github.com/threefoldtech/guardians_healthchecker/spawner/internal/metrics"
+	spawner "github.com/threefoldtech/guardians_healthchecker/spawner/pkg/spawner"
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-client/deployer"
+	"gotest.tools/assert"
+)
+
+func TestServer(t *testing.T) {
+	store, err := history.NewStore(filepath.Join(t.TempDir(), "history.db"))
+	assert.NilError(t, err)
+
+	run := history.Run{ID: "run-1", Farms: []uint64{1}, Status: history.CompletedStatus, StartedAt: time.Now().UTC().Truncate(time.Second)}
+	assert.NilError(t, store.Save(run))
+
+	cfg := spawner.Config{
+		Farms:    []uint64{1},
+		Profiles: map[string]spawner.VMProfile{"large": {CPU: 8, Memory: 16, RootSize: 100}},
+	}
+	handler := New(cfg, deployer.TFPluginClient{}, store, metrics.New(), "secret").Handler()
+
+	request := func(method, path, body, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("missing token", func(t *testing.T) {
+		rec := request(http.MethodGet, "/runs", "", "")
+		assert.Equal(t, rec.Code, http.StatusUnauthorized)
+	})
+	t.Run("invalid token", func(t *testing.T) {
+		rec := request(http.MethodGet, "/runs", "", "wrong")
+		assert.Equal(t, rec.Code, http.StatusUnauthorized)
+	})
+	t.Run("metrics without token", func(t *testing.T) {
+		rec := request(http.MethodGet, "/metrics", "", "")
+		assert.Equal(t, rec.Code, http.StatusOK)
+	})
+	t.Run("list runs", func(t *testing.T) {
+		rec := request(http.MethodGet, "/runs", "", "secret")
+		assert.Equal(t, rec.Code, http.StatusOK)
+
+		var runs []history.Run
+		assert.NilError(t, json.NewDecoder(rec.Body).Decode(&runs))
+		assert.Equal(t, len(runs), 1)
+		assert.Equal(t, runs[0].ID, "run-1")
+	})
+	t.Run("get run", func(t *testing.T) {
+		rec := request(http.MethodGet, "/runs/run-1", "", "secret")
+		assert.Equal(t, rec.Code, http.StatusOK)
+
+		var got history.Run
+		assert.NilError(t, json.NewDecoder(rec.Body).Decode(&got))
+		assert.DeepEqual(t, got, run)
+	})
+	t.Run("unknown run", func(t *testing.T) {
+		rec := request(http.MethodGet, "/runs/missing", "", "secret")
+		assert.Equal(t, rec.Code, http.StatusNotFound)
+
+		rec = request(http.MethodDelete, "/runs/missing", "", "secret")
+		assert.Equal(t, rec.Code, http.StatusNotFound)
+	})
+	t.Run("invalid run request", func(t *testing.T) {
+		rec := request(http.MethodPost, "/runs", `{"farms": []}`, "secret")
+		assert.Equal(t, rec.Code, http.StatusBadRequest)
+
+		rec = request(http.MethodPost, "/runs", `{"farms": [1], "profile": "huge"}`, "secret")
+		assert.Equal(t, rec.Code, http.StatusBadRequest)
+		var response errorResponse
+		assert.NilError(t, json.NewDecoder(rec.Body).Decode(&response))
+		assert.Equal(t, response.Error, "invalid run request: unknown profile huge")
+
+		rec = request(http.MethodPost, "/runs", `{"farms": [1], "unknown": true}`, "secret")
+		assert.Equal(t, rec.Code, http.StatusBadRequest)
+
+		rec = request(http.MethodPost, "/runs", `{"farms": [1], "deployment_strategy": 2}`, "secret")
+		assert.Equal(t, rec.Code, http.StatusBadRequest)
+
+		rec = request(http.MethodPost, "/runs", `{"farms": [1], "failure_strategy": "`+strings.Repeat("a", maxRequestSize)+`"}`, "secret")
+		assert.Equal(t, rec.Code, http.StatusRequestEntityTooLarge)
+	})
+}
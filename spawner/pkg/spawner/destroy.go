@@ -2,36 +2,310 @@ package spawner
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/hashicorp/go-multierror"
+	"github.com/rs/zerolog/log"
+	"github.com/sethvargo/go-retry"
 	"github.com/threefoldtech/tfgrid-sdk-go/grid-client/deployer"
 	"github.com/threefoldtech/tfgrid-sdk-go/grid-client/workloads"
+	"golang.org/x/sync/errgroup"
 )
 
-// Destroy destroys VMs
-func Destroy(ctx context.Context, cfg Config, tfPluginClient deployer.TFPluginClient) error {
-	names := []string{}
+// Represents the destroy configuration
+const (
+	defaultDestroyConcurrency = 4
+	destroyRetryInterval      = 5 * time.Second
+)
+
+// DestroyOptions controls how the deployments are destroyed, when any of the filters is set
+// only the deployments matching all the set filters are destroyed.
+// The VM and the network on a node are always destroyed together.
+type DestroyOptions struct {
+	// Concurrency is the maximum number of farms destroyed in parallel
+	Concurrency int
+
+	// Nodes only destroys the deployments on these nodes
+	Nodes []uint32
+	// Contracts only destroys the deployments of these contracts
+	Contracts []uint64
+	// RunID only destroys the deployments created by this run
+	RunID string
+	// OlderThan only destroys the deployments created more than this duration ago
+	OlderThan time.Duration
+	// FailedOnly only destroys the deployments with workloads in error
+	FailedOnly bool
+}
+
+// selective reports whether any of the destroy filters is set
+func (o DestroyOptions) selective() bool {
+	return len(o.Nodes) != 0 || len(o.Contracts) != 0 || o.RunID != "" || o.OlderThan != 0 || o.FailedOnly
+}
+
+// needsDeployment reports whether any of the set destroy filters is checked against the deployment on the node,
+// such filters can not match the deployments which could not be fetched from their node
+func (o DestroyOptions) needsDeployment() bool {
+	return o.RunID != "" || o.OlderThan != 0 || o.FailedOnly
+}
+
+// matchesContract reports whether a deployment matches the destroy filters checked against its contract
+func (o DestroyOptions) matchesContract(info VMInfo) bool {
+	if len(o.Nodes) != 0 && !slices.Contains(o.Nodes, info.Node) {
+		return false
+	}
+	if len(o.Contracts) != 0 && !slices.Contains(o.Contracts, info.Contract) {
+		return false
+	}
+
+	return true
+}
+
+// matches reports whether a deployment matches all the set destroy filters
+func (o DestroyOptions) matches(info VMInfo, now time.Time) bool {
+	if !o.matchesContract(info) {
+		return false
+	}
+	if o.RunID != "" && info.RunID != o.RunID {
+		return false
+	}
+	if o.OlderThan != 0 && (info.Created.IsZero() || now.Sub(info.Created) < o.OlderThan) {
+		return false
+	}
+	if o.FailedOnly && !info.Failed {
+		return false
+	}
+
+	return true
+}
+
+// selectContracts returns the contracts to cancel out of the given deployments,
+// if a deployment on a node matches the filters all the deployments on that node are selected.
+// It also returns the unverified contracts, whose deployment could not be fetched from their node
+// to check the filters against, they are reported instead of being silently skipped.
+func selectContracts(infos []VMInfo, opts DestroyOptions, now time.Time) (contracts, unverified []uint64) {
+	matchedNodes := make(map[uint32]bool)
+	for _, info := range infos {
+		if opts.matches(info, now) {
+			matchedNodes[info.Node] = true
+		}
+	}
 
-	for _, farm := range cfg.Farms {
-		names = append(names, fmt.Sprintf("vm/%d", farm))
+	for _, info := range infos {
+		switch {
+		case matchedNodes[info.Node]:
+			contracts = append(contracts, info.Contract)
+		case info.Error != "" && opts.needsDeployment() && opts.matchesContract(info):
+			unverified = append(unverified, info.Contract)
+		}
 	}
 
-	return destroy(tfPluginClient, names)
+	slices.Sort(contracts)
+	slices.Sort(unverified)
+	return contracts, unverified
+}
+
+// Destroy destroys VMs
+func Destroy(ctx context.Context, cfg Config, tfPluginClient deployer.TFPluginClient, opts DestroyOptions) (DestroyResult, error) {
+	plan, _ := PlanDestroy(ctx, cfg, tfPluginClient, opts)
+
+	return ExecuteDestroy(ctx, tfPluginClient, plan, opts)
 }
 
-// destroy destroys VMs by names
-func destroy(tfPluginClient deployer.TFPluginClient, names []string) error {
-	var resultErr *multierror.Error
+// PlanDestroy lists the contracts of every farm in the config file selected by the destroy options without cancelling them.
+func PlanDestroy(ctx context.Context, cfg Config, tfPluginClient deployer.TFPluginClient, opts DestroyOptions) (DestroyPlan, error) {
+	var (
+		plan      DestroyPlan
+		resultErr *multierror.Error
+		mu        sync.Mutex
+	)
+
+	forEachFarm(cfg.Farms, opts.Concurrency, func(farm uint64) {
+		contracts, unverified, err := farmContracts(ctx, tfPluginClient, farm, opts)
+		farmPlan := FarmDestroyPlan{Farm: farm, Contracts: contracts, Unverified: unverified}
+		if err != nil {
+			farmPlan.Error = err.Error()
+		}
+		if len(unverified) != 0 {
+			log.Warn().Uint64("Farm", farm).Uints64("Contracts", unverified).Msg("contracts on unreachable nodes could not be checked against the filters and are not cancelled")
+		}
+
+		mu.Lock()
+		plan.Farms = append(plan.Farms, farmPlan)
+		if err != nil {
+			resultErr = multierror.Append(resultErr, err)
+		}
+		mu.Unlock()
+	})
+
+	sort.Slice(plan.Farms, func(i, j int) bool {
+		return plan.Farms[i].Farm < plan.Farms[j].Farm
+	})
+
+	return plan, resultErr.ErrorOrNil()
+}
+
+// ExecuteDestroy cancels the contracts of a destroy plan, farms which failed to be planned are reported as failed.
+func ExecuteDestroy(ctx context.Context, tfPluginClient deployer.TFPluginClient, plan DestroyPlan, opts DestroyOptions) (DestroyResult, error) {
+	var (
+		result    DestroyResult
+		resultErr *multierror.Error
+		mu        sync.Mutex
+	)
+
+	farms := make(map[uint64]FarmDestroyPlan, len(plan.Farms))
+	farmIDs := make([]uint64, 0, len(plan.Farms))
+	for _, farmPlan := range plan.Farms {
+		farms[farmPlan.Farm] = farmPlan
+		farmIDs = append(farmIDs, farmPlan.Farm)
+	}
+
+	forEachFarm(farmIDs, opts.Concurrency, func(farm uint64) {
+		farmResult, err := destroyFarm(ctx, tfPluginClient, farms[farm])
+		farmResult.Unverified = farms[farm].Unverified
+		if err != nil {
+			farmResult.Error = err.Error()
+			log.Error().Err(err).Uint64("Farm", farm).Msg("failed to destroy deployments")
+		} else {
+			log.Info().Uint64("Farm", farm).Msgf("cancelled %d contracts", len(farmResult.Cancelled))
+		}
 
-	for _, name := range names {
-		err := tfPluginClient.CancelByProjectName(name, true)
+		mu.Lock()
+		result.Farms = append(result.Farms, farmResult)
 		if err != nil {
 			resultErr = multierror.Append(resultErr, err)
 		}
+		mu.Unlock()
+	})
+
+	sort.Slice(result.Farms, func(i, j int) bool {
+		return result.Farms[i].Farm < result.Farms[j].Farm
+	})
+
+	return result, resultErr.ErrorOrNil()
+}
+
+// forEachFarm runs fn for every farm with at most concurrency farms in parallel
+func forEachFarm(farms []uint64, concurrency int, fn func(farm uint64)) {
+	if concurrency <= 0 {
+		concurrency = defaultDestroyConcurrency
+	}
+
+	var group errgroup.Group
+	group.SetLimit(concurrency)
+
+	for _, farm := range farms {
+		farm := farm
+		group.Go(func() error {
+			fn(farm)
+			return nil
+		})
+	}
+
+	_ = group.Wait()
+}
+
+// destroyFarm cancels the planned contracts of a farm, then verifies they are gone and retries the remaining ones
+func destroyFarm(ctx context.Context, tfPluginClient deployer.TFPluginClient, plan FarmDestroyPlan) (FarmDestroyResult, error) {
+	result := FarmDestroyResult{Farm: plan.Farm}
+	if plan.Error != "" {
+		return result, errors.New(plan.Error)
+	}
+
+	if len(plan.Contracts) == 0 {
+		log.Info().Uint64("Farm", plan.Farm).Msg("no contracts to cancel")
+		return result, nil
+	}
+
+	var err error
+	result.Remaining, result.Cancelled, result.Attempts, err = cancelContracts(ctx, tfPluginClient, plan.Contracts)
+	if err != nil {
+		return result, fmt.Errorf("failed to cancel contracts of farm %d: %w", plan.Farm, err)
+	}
+
+	return result, nil
+}
+
+// farmContracts returns the contracts of a farm selected by the destroy options, along with the
+// contracts on unreachable nodes which could not be checked against them
+func farmContracts(ctx context.Context, tfPluginClient deployer.TFPluginClient, farm uint64, opts DestroyOptions) (contracts, unverified []uint64, err error) {
+	if opts.selective() {
+		infos, err := processFarm(ctx, farm, tfPluginClient)
+		if err != nil {
+			// deployments on unreachable nodes can still be selected by node or contract
+			log.Warn().Err(err).Uint64("Farm", farm).Msg("some deployments could not be fetched")
+		}
+		if len(infos) == 0 && err != nil {
+			return nil, nil, err
+		}
+
+		contracts, unverified := selectContracts(infos, opts, time.Now())
+		return contracts, unverified, nil
+	}
+
+	name := fmt.Sprintf("vm/%d", farm)
+	nodeContracts, err := tfPluginClient.ContractsGetter.ListContractsOfProjectName(name, true)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error listing contracts for farm %d: %w", farm, err)
+	}
+
+	contractIDs := make([]uint64, 0, len(nodeContracts.NodeContracts))
+	for _, contract := range nodeContracts.NodeContracts {
+		contractID, err := strconv.ParseUint(contract.ContractID, 10, 64)
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not parse contract %s of farm %d: %w", contract.ContractID, farm, err)
+		}
+		contractIDs = append(contractIDs, contractID)
+	}
+
+	return contractIDs, nil, nil
+}
+
+// cancelContracts cancels the given contracts and verifies they are no longer active on the chain,
+// contracts which are still active are retried. It returns the remaining and cancelled contracts.
+func cancelContracts(ctx context.Context, tfPluginClient deployer.TFPluginClient, contracts []uint64) (remaining, cancelled []uint64, attempts int, err error) {
+	remaining = contracts
+	var cancelErr error
+
+	err = retry.Do(ctx, retry.WithMaxRetries(defaultMaxRetries, retry.NewConstant(destroyRetryInterval)), func(ctx context.Context) error {
+		attempts++
+		if attempts != 1 {
+			log.Info().Int("Retry", attempts).Uints64("Contracts", remaining).Msg("retrying contracts cancellation")
+		}
+
+		cancelErr = tfPluginClient.BatchCancelContract(remaining)
+		if cancelErr != nil {
+			log.Debug().Err(cancelErr).Msg("contracts cancellation failed")
+		}
+
+		var stillActive []uint64
+		for _, contractID := range remaining {
+			valid, err := tfPluginClient.SubstrateConn.IsValidContract(contractID)
+			if err != nil || valid {
+				stillActive = append(stillActive, contractID)
+				continue
+			}
+			cancelled = append(cancelled, contractID)
+		}
+		remaining = stillActive
+
+		if len(remaining) == 0 {
+			return nil
+		}
+
+		return retry.RetryableError(fmt.Errorf("%d contracts are still active: %v", len(remaining), remaining))
+	})
+
+	if err != nil && cancelErr != nil {
+		err = fmt.Errorf("%w: %w", err, cancelErr)
 	}
 
-	return resultErr.ErrorOrNil()
+	return remaining, cancelled, attempts, err
 }
 
 // destroyFailingNetworks destroys failing networks
@@ -0,0 +1,75 @@
+package spawner
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestSelectContracts(t *testing.T) {
+	now := time.Now()
+	infos := []VMInfo{
+		{Node: 1, Contract: 10, Type: "network", RunID: "run-1", Created: now.Add(-48 * time.Hour)},
+		{Node: 1, Contract: 11, Type: "vm", RunID: "run-1", Created: now.Add(-48 * time.Hour)},
+		{Node: 2, Contract: 20, Type: "network", RunID: "run-2", Created: now.Add(-time.Hour)},
+		{Node: 2, Contract: 21, Type: "vm", RunID: "run-2", Created: now.Add(-time.Hour), Failed: true},
+		{Node: 3, Contract: 31, Type: "vm", Error: "node is unreachable"},
+	}
+
+	tests := []struct {
+		name       string
+		opts       DestroyOptions
+		contracts  []uint64
+		unverified []uint64
+	}{
+		{
+			name:      "by node",
+			opts:      DestroyOptions{Nodes: []uint32{2, 3}},
+			contracts: []uint64{20, 21, 31},
+		},
+		{
+			name:      "by contract selects the whole node",
+			opts:      DestroyOptions{Contracts: []uint64{11}},
+			contracts: []uint64{10, 11},
+		},
+		{
+			name:       "by run",
+			opts:       DestroyOptions{RunID: "run-2"},
+			contracts:  []uint64{20, 21},
+			unverified: []uint64{31},
+		},
+		{
+			name:       "older than",
+			opts:       DestroyOptions{OlderThan: 24 * time.Hour},
+			contracts:  []uint64{10, 11},
+			unverified: []uint64{31},
+		},
+		{
+			name:       "failed only",
+			opts:       DestroyOptions{FailedOnly: true},
+			contracts:  []uint64{20, 21},
+			unverified: []uint64{31},
+		},
+		{
+			name:       "all filters must match",
+			opts:       DestroyOptions{RunID: "run-1", FailedOnly: true},
+			unverified: []uint64{31},
+		},
+		{
+			name: "unreachable nodes outside the node filter are not reported",
+			opts: DestroyOptions{Nodes: []uint32{1}, RunID: "run-2"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Assert(t, test.opts.selective())
+			contracts, unverified := selectContracts(infos, test.opts, now)
+			assert.DeepEqual(t, contracts, test.contracts)
+			assert.DeepEqual(t, unverified, test.unverified)
+		})
+	}
+
+	assert.Assert(t, !DestroyOptions{Concurrency: 2}.selective())
+}
@@ -0,0 +1,194 @@
+package spawner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrorClass identifies the kind of a deployment failure
+type ErrorClass string
+
+// Represents the classes of deployment failures
+const (
+	NodeUnreachableClass        ErrorClass = "node_unreachable"
+	InsufficientCapacityClass   ErrorClass = "insufficient_capacity"
+	InsufficientBalanceClass    ErrorClass = "insufficient_balance"
+	ContractCreationFailedClass ErrorClass = "contract_creation_failed"
+	WorkloadErrorClass          ErrorClass = "workload_error"
+	TimeoutClass                ErrorClass = "timeout"
+	AbortedClass                ErrorClass = "aborted"
+	GridUnreachableClass        ErrorClass = "grid_unreachable"
+	UnknownErrorClass           ErrorClass = "unknown"
+)
+
+// NodeAttributed reports whether a failure of this class is caused by the node, failures caused by the twin
+// balance, by stopping the deployment or by an unreachable grid proxy say nothing about the health of the node
+func (c ErrorClass) NodeAttributed() bool {
+	return c != AbortedClass && c != InsufficientBalanceClass && c != GridUnreachableClass
+}
+
+// workloadErrRegex extracts the workload name and zos error from the errors returned while waiting for a deployment
+var workloadErrRegex = regexp.MustCompile(`workload (\S+) (?:within deployment \d+ failed with error|state within deployment \d+ is \w+|within deployment \d+ was not updated): (.*)`)
+
+// nodeError holds the node and the underlying grid-client error of a deployment failure
+type nodeError struct {
+	Node uint32
+	Err  error
+}
+
+// Unwrap returns the underlying grid-client error
+func (e nodeError) Unwrap() error {
+	return e.Err
+}
+
+// NodeUnreachableError is returned when the node could not be reached over RMB
+type NodeUnreachableError struct{ nodeError }
+
+func (e NodeUnreachableError) Error() string {
+	return fmt.Sprintf("node %d is unreachable: %v", e.Node, e.Err)
+}
+
+// InsufficientCapacityError is returned when the node does not have enough free resources for the VM
+type InsufficientCapacityError struct{ nodeError }
+
+func (e InsufficientCapacityError) Error() string {
+	return fmt.Sprintf("node %d has insufficient capacity: %v", e.Node, e.Err)
+}
+
+// InsufficientBalanceError is returned when the twin can not pay for the contracts
+type InsufficientBalanceError struct{ nodeError }
+
+func (e InsufficientBalanceError) Error() string {
+	return fmt.Sprintf("insufficient balance to deploy on node %d: %v", e.Node, e.Err)
+}
+
+// ContractCreationError is returned when the node contract could not be created on the chain
+type ContractCreationError struct{ nodeError }
+
+func (e ContractCreationError) Error() string {
+	return fmt.Sprintf("failed to create contract on node %d: %v", e.Node, e.Err)
+}
+
+// WorkloadError is returned when zos reports an error for one of the deployment workloads
+type WorkloadError struct {
+	nodeError
+	Workload string
+	Message  string
+}
+
+func (e WorkloadError) Error() string {
+	return fmt.Sprintf("workload %s on node %d failed: %s", e.Workload, e.Node, e.Message)
+}
+
+// TimeoutError is returned when the deployment did not finish in time
+type TimeoutError struct{ nodeError }
+
+func (e TimeoutError) Error() string {
+	return fmt.Sprintf("deployment on node %d timed out: %v", e.Node, e.Err)
+}
+
+// AbortedError is returned when the deployment was stopped before the node could finish it,
+// because it was cancelled or destroyed by the destroy-all failure strategy
+type AbortedError struct{ nodeError }
+
+func (e AbortedError) Error() string {
+	return fmt.Sprintf("deployment on node %d was aborted: %v", e.Node, e.Err)
+}
+
+// GridUnreachableError is returned when the grid proxy could not be reached to look up the nodes of a farm
+type GridUnreachableError struct {
+	Err error
+}
+
+func (e GridUnreachableError) Error() string {
+	return fmt.Sprintf("grid proxy is unreachable: %v", e.Err)
+}
+
+// Unwrap returns the underlying grid proxy error
+func (e GridUnreachableError) Unwrap() error {
+	return e.Err
+}
+
+// ClassOf returns the class of a deployment error
+func ClassOf(err error) ErrorClass {
+	if err == nil {
+		return ""
+	}
+
+	var (
+		unreachableErr NodeUnreachableError
+		capacityErr    InsufficientCapacityError
+		balanceErr     InsufficientBalanceError
+		contractErr    ContractCreationError
+		workloadErr    WorkloadError
+		timeoutErr     TimeoutError
+		abortedErr     AbortedError
+		gridErr        GridUnreachableError
+	)
+
+	switch {
+	case errors.As(err, &unreachableErr):
+		return NodeUnreachableClass
+	case errors.As(err, &capacityErr):
+		return InsufficientCapacityClass
+	case errors.As(err, &balanceErr):
+		return InsufficientBalanceClass
+	case errors.As(err, &contractErr):
+		return ContractCreationFailedClass
+	case errors.As(err, &workloadErr):
+		return WorkloadErrorClass
+	case errors.As(err, &timeoutErr):
+		return TimeoutClass
+	case errors.As(err, &abortedErr):
+		return AbortedClass
+	case errors.As(err, &gridErr):
+		return GridUnreachableClass
+	}
+
+	return UnknownErrorClass
+}
+
+// classifyError wraps a grid-client error of a node deployment into the matching typed error,
+// grid-client does not export typed errors so the classification relies on the error messages
+func classifyError(node uint32, err error) error {
+	if err == nil || ClassOf(err) != UnknownErrorClass {
+		return err
+	}
+
+	base := nodeError{Node: node, Err: err}
+	msg := strings.ToLower(err.Error())
+
+	if match := workloadErrRegex.FindStringSubmatch(err.Error()); len(match) == 3 {
+		return WorkloadError{nodeError: base, Workload: match[1], Message: match[2]}
+	}
+
+	switch {
+	case containsAny(msg, "min fee", "balance", "inability to pay"):
+		return InsufficientBalanceError{base}
+	case containsAny(msg, "enough resources", "not enough capacity", "could not find enough nodes"):
+		return InsufficientCapacityError{base}
+	case containsAny(msg, "node client", "failed to get node", "error sending deployment", "rmb", "connection refused"):
+		return NodeUnreachableError{base}
+	case errors.Is(err, context.Canceled) || containsAny(msg, "context canceled"):
+		return AbortedError{base}
+	case errors.Is(err, context.DeadlineExceeded) || containsAny(msg, "timed out", "timeout", "deadline exceeded"):
+		return TimeoutError{base}
+	case containsAny(msg, "failed to create contracts", "create contract"):
+		return ContractCreationError{base}
+	}
+
+	return err
+}
+
+// containsAny reports whether s contains any of the given substrings
+func containsAny(s string, substrings ...string) bool {
+	for _, substring := range substrings {
+		if strings.Contains(s, substring) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package spawner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/go-multierror"
+	"gotest.tools/assert"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name  string
+		err   error
+		class ErrorClass
+	}{
+		{
+			name:  "node unreachable",
+			err:   errors.New("failed to get node 12 client: could not find node"),
+			class: NodeUnreachableClass,
+		},
+		{
+			name:  "insufficient capacity",
+			err:   errors.New("node 12 does not have enough resources. needed: cru: 4, free: cru: 2"),
+			class: InsufficientCapacityClass,
+		},
+		{
+			name:  "insufficient balance",
+			err:   errors.New("account contains 1.000000 tft, min fee is 2 tft"),
+			class: InsufficientBalanceClass,
+		},
+		{
+			name:  "contract creation",
+			err:   errors.New("failed to create contracts: extrinsic failed"),
+			class: ContractCreationFailedClass,
+		},
+		{
+			name:  "workload error",
+			err:   errors.New("error waiting deployment on node 12: workload vm_12 within deployment 55 failed with error: failed to download flist"),
+			class: WorkloadErrorClass,
+		},
+		{
+			name:  "timeout",
+			err:   errors.New("error waiting deployment on node 12: waiting for deployment 55 timed out"),
+			class: TimeoutClass,
+		},
+		{
+			name:  "aborted",
+			err:   fmt.Errorf("error waiting deployment on node 12: %w", context.Canceled),
+			class: AbortedClass,
+		},
+		{
+			name:  "unknown",
+			err:   errors.New("something went wrong"),
+			class: UnknownErrorClass,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := classifyError(12, test.err)
+			assert.Equal(t, ClassOf(err), test.class)
+			assert.Assert(t, errors.Is(err, test.err))
+		})
+	}
+
+	t.Run("grid unreachable", func(t *testing.T) {
+		pingErr := errors.New("failed to ping the grid proxy: connection refused")
+		err := classifyError(0, GridUnreachableError{Err: pingErr})
+		assert.Equal(t, ClassOf(err), GridUnreachableClass)
+		assert.Assert(t, errors.Is(err, pingErr))
+		assert.Assert(t, !GridUnreachableClass.NodeAttributed())
+	})
+	t.Run("workload details", func(t *testing.T) {
+		err := classifyError(12, errors.New("workload vm_12 within deployment 55 failed with error: failed to download flist"))
+
+		var workloadErr WorkloadError
+		assert.Assert(t, errors.As(err, &workloadErr))
+		assert.Equal(t, workloadErr.Node, uint32(12))
+		assert.Equal(t, workloadErr.Workload, "vm_12")
+		assert.Equal(t, workloadErr.Message, "failed to download flist")
+	})
+}
+
+func TestNodeErrors(t *testing.T) {
+	err := multierror.Append(nil,
+		fmt.Errorf("error waiting deployment on node 11: failed"),
+		multierror.Append(nil, fmt.Errorf("failed to get node 12 client: failed")),
+		fmt.Errorf("failed to create contracts"),
+	)
+
+	errs := nodeErrors(err)
+	assert.Equal(t, len(errs), 2)
+	assert.ErrorContains(t, errs[11], "node 11")
+	assert.ErrorContains(t, errs[12], "node 12")
+}
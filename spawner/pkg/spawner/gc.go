@@ -0,0 +1,232 @@
+package spawner
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/rs/zerolog/log"
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-client/deployer"
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-client/workloads"
+	"golang.org/x/sync/errgroup"
+)
+
+// OrphanReason describes why a benchmark contract is considered orphaned
+type OrphanReason string
+
+// Represents the reasons of orphaned contracts
+const (
+	NetworkWithoutVMReason OrphanReason = "network_without_vm"
+	ErroredWorkloadsReason OrphanReason = "errored_workloads"
+	UnknownRunReason       OrphanReason = "unknown_run"
+	ExpiredReason          OrphanReason = "expired"
+)
+
+// projectNameRegex matches the project names of benchmark deployments
+var projectNameRegex = regexp.MustCompile(`^vm/(\d+)$`)
+
+// GCOptions controls which benchmark contracts are considered orphaned
+type GCOptions struct {
+	// KnownRuns are the runs whose deployments are kept, if empty only deployments without a run are orphaned
+	KnownRuns []string
+	// ActiveRuns are the runs still in progress, none of their deployments is orphaned
+	ActiveRuns []string
+	// Grace keeps the deployments created less than this duration ago, they may belong to a spawn still in progress
+	Grace time.Duration
+}
+
+// FindOrphans scans all the contracts of the twin and returns the benchmark contracts which are orphaned:
+// networks without VMs, deployments with errored workloads and deployments from unknown runs.
+func FindOrphans(ctx context.Context, tfPluginClient deployer.TFPluginClient, opts GCOptions) ([]Orphan, error) {
+	infos, err := listBenchmarkDeployments(ctx, tfPluginClient)
+	if len(infos) == 0 && err != nil {
+		return nil, err
+	}
+
+	return findOrphans(infos, opts, time.Now()), err
+}
+
+// FindExpired scans all the contracts of the twin and returns the benchmark contracts past their expiry.
+func FindExpired(ctx context.Context, tfPluginClient deployer.TFPluginClient, now time.Time) ([]Orphan, error) {
+	infos, err := listBenchmarkDeployments(ctx, tfPluginClient)
+	if len(infos) == 0 && err != nil {
+		return nil, err
+	}
+
+	return findExpired(infos, now), err
+}
+
+// Reap cancels all the benchmark contracts of the twin past their expiry.
+func Reap(ctx context.Context, tfPluginClient deployer.TFPluginClient) ([]Orphan, DestroyResult, error) {
+	expired, err := FindExpired(ctx, tfPluginClient, time.Now())
+	if err != nil {
+		log.Warn().Err(err).Msg("some deployments could not be fetched")
+	}
+	if len(expired) == 0 {
+		return nil, DestroyResult{}, err
+	}
+
+	result, err := CancelOrphans(ctx, tfPluginClient, expired)
+	return expired, result, err
+}
+
+// listBenchmarkDeployments returns the info of all the benchmark deployments of the twin
+func listBenchmarkDeployments(ctx context.Context, tfPluginClient deployer.TFPluginClient) ([]VMInfo, error) {
+	contracts, err := tfPluginClient.ContractsGetter.ListContractsByTwinID([]string{"Created", "GracePeriod"})
+	if err != nil {
+		return nil, fmt.Errorf("error listing contracts: %w", err)
+	}
+
+	var (
+		infos     []VMInfo
+		resultErr *multierror.Error
+		group     errgroup.Group
+		mu        sync.Mutex
+	)
+	group.SetLimit(10)
+
+	for _, contract := range contracts.NodeContracts {
+		deploymentData, err := workloads.ParseDeploymentData(contract.DeploymentData)
+		if err != nil {
+			continue
+		}
+		match := projectNameRegex.FindStringSubmatch(deploymentData.ProjectName)
+		if len(match) != 2 {
+			continue
+		}
+		farm, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		contract := contract
+		group.Go(func() error {
+			info, err := processContract(ctx, contract, farm, deploymentData.ProjectName, tfPluginClient)
+			mu.Lock()
+			defer mu.Unlock()
+			if info != nil {
+				infos = append(infos, *info)
+			}
+			if err != nil {
+				resultErr = multierror.Append(resultErr, err)
+			}
+			return nil
+		})
+	}
+
+	_ = group.Wait()
+
+	return infos, resultErr.ErrorOrNil()
+}
+
+// CancelOrphans cancels the orphaned contracts and verifies they are gone.
+func CancelOrphans(ctx context.Context, tfPluginClient deployer.TFPluginClient, orphans []Orphan) (DestroyResult, error) {
+	contracts := make(map[uint64][]uint64)
+	for _, orphan := range orphans {
+		contracts[orphan.Farm] = append(contracts[orphan.Farm], orphan.Contract)
+	}
+
+	var plan DestroyPlan
+	for farm, farmContracts := range contracts {
+		plan.Farms = append(plan.Farms, FarmDestroyPlan{Farm: farm, Contracts: farmContracts})
+	}
+
+	return ExecuteDestroy(ctx, tfPluginClient, plan, DestroyOptions{})
+}
+
+// nodeKey identifies the deployments of a project on a node
+type nodeKey struct {
+	project string
+	node    uint32
+}
+
+// findExpired returns the deployments past their expiry, when a deployment on a node is expired
+// all the deployments of the same project on that node are expired as well
+func findExpired(infos []VMInfo, now time.Time) []Orphan {
+	reasons := make(map[nodeKey]OrphanReason)
+	for _, info := range infos {
+		if !info.ExpiresAt.IsZero() && !info.ExpiresAt.After(now) {
+			reasons[nodeKey{info.ProjectName, info.Node}] = ExpiredReason
+		}
+	}
+
+	return groupOrphans(infos, reasons)
+}
+
+// findOrphans returns the orphaned deployments, when a deployment on a node is orphaned
+// all the deployments of the same project on that node are orphaned as well
+func findOrphans(infos []VMInfo, opts GCOptions, now time.Time) []Orphan {
+	hasVM := make(map[nodeKey]bool)
+	for _, info := range infos {
+		if info.Type == workloads.VMType {
+			hasVM[nodeKey{info.ProjectName, info.Node}] = true
+		}
+	}
+
+	reasons := make(map[nodeKey]OrphanReason)
+	for _, info := range infos {
+		key := nodeKey{info.ProjectName, info.Node}
+		if _, ok := reasons[key]; ok {
+			continue
+		}
+
+		switch {
+		case info.Error != "":
+			// the state of deployments on unreachable nodes is unknown
+			continue
+		case slices.Contains(opts.ActiveRuns, info.RunID) || opts.young(info, now):
+			// a spawn in progress deploys the networks before the VMs
+			continue
+		case info.Type == workloads.NetworkType && !hasVM[key]:
+			reasons[key] = NetworkWithoutVMReason
+		case info.Failed:
+			reasons[key] = ErroredWorkloadsReason
+		case info.RunID == "" || (len(opts.KnownRuns) != 0 && !slices.Contains(opts.KnownRuns, info.RunID)):
+			reasons[key] = UnknownRunReason
+		}
+	}
+
+	return groupOrphans(infos, reasons)
+}
+
+// young reports whether the deployment was created within the grace period, deployments
+// whose workloads did not report their creation yet are young as well
+func (o GCOptions) young(info VMInfo, now time.Time) bool {
+	if o.Grace <= 0 {
+		return false
+	}
+	return info.Created.IsZero() || now.Sub(info.Created) < o.Grace
+}
+
+// groupOrphans returns all the deployments on the nodes with an orphan reason
+func groupOrphans(infos []VMInfo, reasons map[nodeKey]OrphanReason) []Orphan {
+	var orphans []Orphan
+	for _, info := range infos {
+		reason, ok := reasons[nodeKey{info.ProjectName, info.Node}]
+		if !ok {
+			continue
+		}
+
+		orphans = append(orphans, Orphan{
+			Farm:     info.Farm,
+			Node:     info.Node,
+			Contract: info.Contract,
+			Name:     info.Name,
+			Type:     info.Type,
+			RunID:    info.RunID,
+			Reason:   reason,
+		})
+	}
+
+	sort.Slice(orphans, func(i, j int) bool {
+		return orphans[i].Contract < orphans[j].Contract
+	})
+
+	return orphans
+}
@@ -0,0 +1,86 @@
+package spawner
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestFindOrphans(t *testing.T) {
+	now := time.Now()
+	infos := []VMInfo{
+		// healthy deployment of a known run
+		{Farm: 1, Node: 1, Contract: 10, Type: "network", ProjectName: "vm/1", RunID: "run-1"},
+		{Farm: 1, Node: 1, Contract: 11, Type: "vm", ProjectName: "vm/1", RunID: "run-1"},
+		// network left behind by a failed batch
+		{Farm: 1, Node: 2, Contract: 20, Type: "network", ProjectName: "vm/1", RunID: "run-1"},
+		// vm with errored workloads
+		{Farm: 1, Node: 3, Contract: 30, Type: "network", ProjectName: "vm/1", RunID: "run-1"},
+		{Farm: 1, Node: 3, Contract: 31, Type: "vm", ProjectName: "vm/1", RunID: "run-1", Failed: true},
+		// deployment of an unknown run
+		{Farm: 2, Node: 4, Contract: 40, Type: "network", ProjectName: "vm/2", RunID: "run-2"},
+		{Farm: 2, Node: 4, Contract: 41, Type: "vm", ProjectName: "vm/2", RunID: "run-2"},
+		// deployment on an unreachable node
+		{Farm: 2, Node: 5, Contract: 50, Type: "network", ProjectName: "vm/2", Error: "unreachable"},
+		{Farm: 2, Node: 5, Contract: 51, Type: "vm", ProjectName: "vm/2", Error: "unreachable"},
+	}
+
+	t.Run("known runs", func(t *testing.T) {
+		orphans := findOrphans(infos, GCOptions{KnownRuns: []string{"run-1"}}, now)
+
+		reasons := make(map[uint64]OrphanReason)
+		for _, orphan := range orphans {
+			reasons[orphan.Contract] = orphan.Reason
+		}
+
+		assert.DeepEqual(t, reasons, map[uint64]OrphanReason{
+			20: NetworkWithoutVMReason,
+			30: ErroredWorkloadsReason,
+			31: ErroredWorkloadsReason,
+			40: UnknownRunReason,
+			41: UnknownRunReason,
+		})
+	})
+
+	t.Run("no known runs", func(t *testing.T) {
+		orphans := findOrphans(infos, GCOptions{}, now)
+		assert.Equal(t, len(orphans), 3)
+	})
+
+	t.Run("runs in progress are kept", func(t *testing.T) {
+		orphans := findOrphans(infos, GCOptions{KnownRuns: []string{"run-1"}, ActiveRuns: []string{"run-1", "run-2"}}, now)
+		assert.Equal(t, len(orphans), 0)
+	})
+
+	t.Run("young deployments are kept", func(t *testing.T) {
+		spawning := []VMInfo{
+			// network of a spawn which did not deploy its VM yet
+			{Farm: 1, Node: 6, Contract: 60, Type: "network", ProjectName: "vm/1", RunID: "run-3", Created: now.Add(-5 * time.Minute)},
+			// network left behind long ago
+			{Farm: 1, Node: 7, Contract: 70, Type: "network", ProjectName: "vm/1", RunID: "run-1", Created: now.Add(-3 * time.Hour)},
+		}
+
+		orphans := findOrphans(spawning, GCOptions{KnownRuns: []string{"run-1"}, Grace: time.Hour}, now)
+		assert.Equal(t, len(orphans), 1)
+		assert.Equal(t, orphans[0].Contract, uint64(70))
+		assert.Equal(t, orphans[0].Reason, NetworkWithoutVMReason)
+	})
+}
+
+func TestFindExpired(t *testing.T) {
+	now := time.Now()
+	infos := []VMInfo{
+		{Farm: 1, Node: 1, Contract: 10, Type: "network", ProjectName: "vm/1", ExpiresAt: now.Add(-time.Minute)},
+		{Farm: 1, Node: 1, Contract: 11, Type: "vm", ProjectName: "vm/1", ExpiresAt: now.Add(-time.Minute)},
+		{Farm: 1, Node: 2, Contract: 20, Type: "network", ProjectName: "vm/1", ExpiresAt: now.Add(time.Hour)},
+		{Farm: 1, Node: 2, Contract: 21, Type: "vm", ProjectName: "vm/1", ExpiresAt: now.Add(time.Hour)},
+		{Farm: 1, Node: 3, Contract: 31, Type: "vm", ProjectName: "vm/1"},
+	}
+
+	expired := findExpired(infos, now)
+	assert.Equal(t, len(expired), 2)
+	assert.Equal(t, expired[0].Contract, uint64(10))
+	assert.Equal(t, expired[1].Contract, uint64(11))
+	assert.Equal(t, expired[1].Reason, ExpiredReason)
+}
@@ -5,58 +5,85 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
 	"strconv"
 	"sync"
 	"text/tabwriter"
+	"time"
 
+	"github.com/hashicorp/go-multierror"
 	"github.com/rs/zerolog/log"
 	"github.com/threefoldtech/tfgrid-sdk-go/grid-client/deployer"
 	"github.com/threefoldtech/tfgrid-sdk-go/grid-client/graphql"
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-client/workloads"
+	"github.com/threefoldtech/zos/pkg/gridtypes"
 	"golang.org/x/sync/errgroup"
 )
 
 // List lists running VMs on specified farms in the config file.
 func List(ctx context.Context, cfg Config, tfPluginClient deployer.TFPluginClient) error {
+	vms, err := Inventory(ctx, cfg, tfPluginClient)
+
+	var running []VMInfo
+	for _, vm := range vms {
+		if vm.Type == workloads.VMType {
+			running = append(running, vm)
+		}
+	}
+
+	displayVMs(running)
+
+	return err
+}
+
+// Inventory returns the benchmark deployments, VMs and networks, on all farms in the config file.
+// The deployments of farms which failed to be listed are missing and their errors are returned.
+func Inventory(ctx context.Context, cfg Config, tfPluginClient deployer.TFPluginClient) ([]VMInfo, error) {
 	var (
-		vms []vmInfo
-		wg  sync.WaitGroup
-		mu  sync.Mutex
+		vms       []VMInfo
+		resultErr *multierror.Error
+		wg        sync.WaitGroup
+		mu        sync.Mutex
 	)
 
 	for _, farm := range cfg.Farms {
 		wg.Add(1)
 		go func(farm uint64) {
 			defer wg.Done()
-			farmVMs := processFarm(ctx, farm, tfPluginClient)
+			farmVMs, err := processFarm(ctx, farm, tfPluginClient)
 			mu.Lock()
 			vms = append(vms, farmVMs...)
+			if err != nil {
+				resultErr = multierror.Append(resultErr, err)
+			}
 			mu.Unlock()
 		}(farm)
 	}
 
 	wg.Wait()
 
-	displayVMs(vms)
+	sort.Slice(vms, func(i, j int) bool {
+		return vms[i].Contract < vms[j].Contract
+	})
 
-	return nil
+	return vms, resultErr.ErrorOrNil()
 }
 
 // processFarm processes all contracts for a given farm and returns a slice of VMs.
-func processFarm(ctx context.Context, farm uint64, tfPluginClient deployer.TFPluginClient) []vmInfo {
+func processFarm(ctx context.Context, farm uint64, tfPluginClient deployer.TFPluginClient) ([]VMInfo, error) {
 	name := fmt.Sprintf("vm/%d", farm)
 	contracts, err := tfPluginClient.ContractsGetter.ListContractsOfProjectName(name, true)
 	if err != nil {
-		fmt.Printf("error listing contracts for farm %d: %v\n", farm, err)
-		return nil
+		return nil, fmt.Errorf("error listing contracts for farm %d: %w", farm, err)
 	}
 	if len(contracts.NodeContracts) == 0 {
 		log.Warn().Msgf("no VMs found for farm %d with project name %s", farm, name)
-		return nil
+		return nil, nil
 	}
 
 	var (
 		farmGroup errgroup.Group
-		vms       []vmInfo
+		vms       []VMInfo
 		mu        sync.Mutex
 	)
 
@@ -64,74 +91,100 @@ func processFarm(ctx context.Context, farm uint64, tfPluginClient deployer.TFPlu
 		contract := contract
 		farmGroup.Go(func() error {
 			vm, err := processContract(ctx, contract, farm, name, tfPluginClient)
-			if err != nil {
-				return err
-			}
 			if vm != nil {
 				mu.Lock()
 				vms = append(vms, *vm)
 				mu.Unlock()
 			}
-			return nil
+			return err
 		})
 	}
 
 	if err := farmGroup.Wait(); err != nil {
-		fmt.Printf("Error processing contracts for farm %d: %v\n", farm, err)
+		return vms, fmt.Errorf("error processing contracts for farm %d: %w", farm, err)
 	}
 
-	return vms
+	return vms, nil
 }
 
-// processContract processes a single contract and returns the VM info.
+// processContract processes a single contract and returns the deployment info,
+// if the deployment can not be fetched from the node the info known from the contract is returned with the error.
 func processContract(
 	ctx context.Context,
 	contract graphql.Contract,
 	farm uint64,
 	name string,
 	tfPluginClient deployer.TFPluginClient,
-) (*vmInfo, error) {
+) (*VMInfo, error) {
 	contractID, err := strconv.ParseUint(contract.ContractID, 10, 64)
 	if err != nil {
 		return nil, err
 	}
 
 	nodeID := contract.NodeID
-	nodeClient, err := tfPluginClient.State.NcPool.GetNodeClient(tfPluginClient.State.Substrate, nodeID)
-	if err != nil {
-		return nil, err
+	info := &VMInfo{
+		Farm:        farm,
+		Node:        nodeID,
+		Contract:    contractID,
+		ProjectName: name,
+	}
+
+	if deploymentData, err := workloads.ParseDeploymentData(contract.DeploymentData); err == nil {
+		info.Name = deploymentData.Name
+		info.Type = deploymentData.Type
 	}
 
-	dl, err := nodeClient.DeploymentGet(ctx, contractID)
+	dl, err := getContractDeployment(ctx, nodeID, contractID, tfPluginClient)
 	if err != nil {
-		return nil, err
+		info.Error = err.Error()
+		return info, err
 	}
 
 	var metadata deploymentMetadata
 	err = json.Unmarshal([]byte(dl.Metadata), &metadata)
 	if err != nil {
-		return nil, err
+		info.Error = err.Error()
+		return info, err
 	}
 
-	if metadata.Type == "vm" {
-		return &vmInfo{
-			Farm:        farm,
-			Node:        nodeID,
-			Name:        metadata.Name,
-			Contract:    contractID,
-			ProjectName: name,
-		}, nil
+	info.Name = metadata.Name
+	info.Type = metadata.Type
+	description := parseDescription(dl)
+	info.RunID = description.RunID
+	info.ExpiresAt = description.expiry()
+
+	for _, wl := range dl.Workloads {
+		created := wl.Result.Created.Time()
+		if !wl.Result.IsNil() && (info.Created.IsZero() || created.Before(info.Created)) {
+			info.Created = created
+		}
 	}
+	info.Failed = hasErroredWorkloads(dl)
 
-	return nil, nil
+	return info, nil
+}
+
+// hasErroredWorkloads reports whether zos reported an error for any workload of the deployment,
+// workloads still provisioning, unchanged or paused are not failed
+func hasErroredWorkloads(dl gridtypes.Deployment) bool {
+	for _, wl := range dl.Workloads {
+		if wl.Result.State == gridtypes.StateError {
+			return true
+		}
+	}
+	return false
 }
 
 // displayVMs prints the list of VMs in a tabular format.
-func displayVMs(vms []vmInfo) {
+func displayVMs(vms []VMInfo) {
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
-	fmt.Fprintln(w, "Farm\tNode\tName\tContract\tProjectName")
+	fmt.Fprintln(w, "Farm\tNode\tName\tContract\tProjectName\tRun\tAge")
 	for _, vm := range vms {
-		fmt.Fprintf(w, "%d\t%d\t%s\t%d\t%s\n", vm.Farm, vm.Node, vm.Name, vm.Contract, vm.ProjectName)
+		age := "-"
+		if !vm.Created.IsZero() {
+			age = time.Since(vm.Created).Round(time.Second).String()
+		}
+		fmt.Fprintf(w, "%d\t%d\t%s\t%d\t%s\t%s\t%s\n", vm.Farm, vm.Node, vm.Name, vm.Contract, vm.ProjectName, vm.RunID, age)
 	}
 	w.Flush()
 }
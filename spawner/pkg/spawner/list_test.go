@@ -0,0 +1,35 @@
+package spawner
+
+import (
+	"testing"
+
+	"github.com/threefoldtech/zos/pkg/gridtypes"
+	"gotest.tools/assert"
+)
+
+func TestHasErroredWorkloads(t *testing.T) {
+	deployment := func(states ...gridtypes.ResultState) gridtypes.Deployment {
+		var dl gridtypes.Deployment
+		for _, state := range states {
+			dl.Workloads = append(dl.Workloads, gridtypes.Workload{Result: gridtypes.Result{State: state}})
+		}
+		return dl
+	}
+
+	tests := []struct {
+		name   string
+		dl     gridtypes.Deployment
+		failed bool
+	}{
+		{name: "ok", dl: deployment(gridtypes.StateOk, gridtypes.StateOk)},
+		{name: "still provisioning", dl: deployment(gridtypes.StateOk, gridtypes.StateInit)},
+		{name: "unchanged and paused", dl: deployment(gridtypes.StateUnChanged, gridtypes.StatePaused)},
+		{name: "errored", dl: deployment(gridtypes.StateOk, gridtypes.StateError), failed: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, hasErroredWorkloads(test.dl), test.failed)
+		})
+	}
+}
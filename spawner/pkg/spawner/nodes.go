@@ -0,0 +1,35 @@
+package spawner
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-client/deployer"
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/types"
+)
+
+// LookupNodes returns the grid proxy records of the given nodes, nodes unknown to the proxy are missing
+func LookupNodes(ctx context.Context, tfPluginClient deployer.TFPluginClient, nodeIDs []uint32) (map[uint32]types.Node, error) {
+	nodes := make(map[uint32]types.Node, len(nodeIDs))
+	if len(nodeIDs) == 0 {
+		return nodes, nil
+	}
+
+	ids := make([]uint64, 0, len(nodeIDs))
+	for _, id := range nodeIDs {
+		ids = append(ids, uint64(id))
+	}
+
+	filter := types.NodeFilter{NodeIDs: ids}
+	limit := types.Limit{Size: uint64(len(ids)), Page: 1}
+	found, _, err := tfPluginClient.GridProxyClient.Nodes(ctx, filter, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up nodes: %w", err)
+	}
+
+	for _, node := range found {
+		nodes[uint32(node.NodeID)] = node
+	}
+
+	return nodes, nil
+}
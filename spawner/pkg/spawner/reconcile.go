@@ -0,0 +1,203 @@
+package spawner
+
+import (
+	"context"
+	"slices"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/rs/zerolog/log"
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-client/deployer"
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-client/workloads"
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/types"
+)
+
+// ReconcileOptions controls a reconciliation pass
+type ReconcileOptions struct {
+	// RunID identifies the new deployments in their description, a new one is generated if empty
+	RunID string
+	// DryRun only computes the changes without applying them
+	DryRun bool
+	// Exclude never deploys on these nodes, such as quarantined ones
+	Exclude []uint32
+}
+
+// nodeState is the state of the benchmark deployments on a node
+type nodeState int
+
+const (
+	healthyNode nodeState = iota
+	brokenNode
+	unknownNode
+)
+
+// Reconcile brings every farm in the config file to its desired number of VMs, the number of nodes
+// selected by the deployment strategy. Deployments with errored workloads are redeployed, missing
+// deployments are created on eligible nodes and extra deployments are destroyed.
+func Reconcile(ctx context.Context, cfg Config, tfPluginClient deployer.TFPluginClient, opts ReconcileOptions) (ReconcileResult, error) {
+	result := ReconcileResult{RunID: opts.RunID}
+	if result.RunID == "" {
+		result.RunID = NewRunID()
+	}
+
+	// a failing deployment is retried on the next pass, destroying the whole farm would also remove the healthy VMs
+	if cfg.FailureStrategy == destroyAllStrategy {
+		cfg.FailureStrategy = destroyFailingStrategy
+	}
+
+	var resultErr *multierror.Error
+	for _, farm := range cfg.Farms {
+		farmResult, err := reconcileFarm(ctx, cfg, tfPluginClient, farm, result.RunID, opts)
+		if err != nil {
+			farmResult.Error = err.Error()
+			resultErr = multierror.Append(resultErr, err)
+			log.Error().Err(err).Uint64("Farm", farm).Msg("failed to reconcile farm")
+		}
+		result.Farms = append(result.Farms, farmResult)
+
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+	}
+
+	return result, resultErr.ErrorOrNil()
+}
+
+// reconcileFarm compares the deployments of a farm with its eligible nodes and applies the needed changes
+func reconcileFarm(ctx context.Context, cfg Config, tfPluginClient deployer.TFPluginClient, farm uint64, runID string, opts ReconcileOptions) (FarmReconcileResult, error) {
+	result := FarmReconcileResult{Farm: farm}
+
+	infos, err := processFarm(ctx, farm, tfPluginClient)
+	if err != nil && len(infos) == 0 {
+		return result, err
+	}
+
+	profile, err := cfg.SelectedProfile()
+	if err != nil {
+		return result, err
+	}
+
+	nodes, err := getNodes(ctx, tfPluginClient, farm, profile)
+	if err != nil {
+		return result, err
+	}
+	nodes = excludeNodes(nodes, opts.Exclude)
+
+	result = planReconcile(farm, infos, nodes, cfg.DeploymentStrategy)
+	log.Info().Uint64("Farm", farm).Int("Desired", result.Desired).Int("Kept", len(result.Kept)).
+		Int("Remove", len(result.Remove)).Int("Deploy", len(result.Deploy)).Msg("reconciling farm")
+	if opts.DryRun {
+		return result, nil
+	}
+
+	if len(result.Remove) != 0 {
+		removed, err := destroyFarm(ctx, tfPluginClient, FarmDestroyPlan{Farm: farm, Contracts: result.Remove})
+		if err != nil {
+			return result, err
+		}
+		log.Info().Uint64("Farm", farm).Msgf("cancelled %d contracts", len(removed.Cancelled))
+	}
+
+	if len(result.Deploy) == 0 {
+		return result, nil
+	}
+
+	var deployNodes []types.Node
+	for _, node := range nodes {
+		if slices.Contains(result.Deploy, uint32(node.NodeID)) {
+			deployNodes = append(deployNodes, node)
+		}
+	}
+
+	result.Deployed, err = spawn(ctx, tfPluginClient, cfg, profile, runID, deployNodes, len(deployNodes))
+	return result, err
+}
+
+// planReconcile computes the changes needed to bring a farm from its current deployments to its desired number of VMs.
+// The deployments of unreachable nodes are left untouched as their state is unknown.
+func planReconcile(farm uint64, infos []VMInfo, nodes []types.Node, strategy float64) FarmReconcileResult {
+	result := FarmReconcileResult{Farm: farm}
+
+	byNode := make(map[uint32][]VMInfo)
+	for _, info := range infos {
+		byNode[info.Node] = append(byNode[info.Node], info)
+	}
+
+	states := make(map[uint32]nodeState, len(byNode))
+	var healthy []uint32
+	for node, nodeInfos := range byNode {
+		states[node] = deploymentState(nodeInfos)
+		if states[node] == healthyNode {
+			healthy = append(healthy, node)
+		}
+	}
+	slices.Sort(healthy)
+
+	// nodes running a VM are usually missing from the eligible nodes since the VM uses their free capacity
+	candidates := len(healthy)
+	for _, node := range nodes {
+		if _, ok := byNode[uint32(node.NodeID)]; !ok || states[uint32(node.NodeID)] == brokenNode {
+			candidates++
+		}
+	}
+	result.Desired = calculateVMCount(candidates, strategy)
+
+	removed := make(map[uint32]bool)
+	for node, state := range states {
+		if state == brokenNode {
+			removed[node] = true
+		}
+	}
+
+	for i, node := range healthy {
+		if i < result.Desired {
+			result.Kept = append(result.Kept, node)
+		} else {
+			removed[node] = true
+		}
+	}
+
+	for _, info := range infos {
+		if removed[info.Node] {
+			result.Remove = append(result.Remove, info.Contract)
+		}
+	}
+	slices.Sort(result.Remove)
+
+	missing := result.Desired - len(result.Kept)
+	for _, node := range nodes {
+		if missing == 0 {
+			break
+		}
+
+		nodeID := uint32(node.NodeID)
+		if _, ok := byNode[nodeID]; ok && states[nodeID] != brokenNode {
+			continue
+		}
+		result.Deploy = append(result.Deploy, nodeID)
+		missing--
+	}
+	slices.Sort(result.Deploy)
+
+	return result
+}
+
+// deploymentState returns the state of the deployments on a single node
+func deploymentState(infos []VMInfo) nodeState {
+	hasVM := false
+	for _, info := range infos {
+		if info.Error != "" {
+			return unknownNode
+		}
+		if info.Failed {
+			return brokenNode
+		}
+		if info.Type == workloads.VMType {
+			hasVM = true
+		}
+	}
+
+	if !hasVM {
+		return brokenNode
+	}
+	return healthyNode
+}
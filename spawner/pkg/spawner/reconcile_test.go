@@ -0,0 +1,44 @@
+package spawner
+
+import (
+	"testing"
+
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/types"
+	"gotest.tools/assert"
+)
+
+func TestPlanReconcile(t *testing.T) {
+	infos := []VMInfo{
+		// healthy deployments
+		{Farm: 1, Node: 1, Contract: 10, Type: "network"},
+		{Farm: 1, Node: 1, Contract: 11, Type: "vm"},
+		{Farm: 1, Node: 2, Contract: 20, Type: "network"},
+		{Farm: 1, Node: 2, Contract: 21, Type: "vm"},
+		// vm with errored workloads
+		{Farm: 1, Node: 3, Contract: 30, Type: "network"},
+		{Farm: 1, Node: 3, Contract: 31, Type: "vm", Failed: true},
+		// network left behind by a failed batch
+		{Farm: 1, Node: 4, Contract: 40, Type: "network"},
+		// deployment on an unreachable node
+		{Farm: 1, Node: 5, Contract: 50, Type: "network", Error: "unreachable"},
+		{Farm: 1, Node: 5, Contract: 51, Type: "vm", Error: "unreachable"},
+	}
+	nodes := []types.Node{{NodeID: 3}, {NodeID: 4}, {NodeID: 6}, {NodeID: 7}}
+
+	t.Run("deploy missing vms", func(t *testing.T) {
+		result := planReconcile(1, infos, nodes, 1)
+
+		assert.Equal(t, result.Desired, 6)
+		assert.DeepEqual(t, result.Kept, []uint32{1, 2})
+		assert.DeepEqual(t, result.Remove, []uint64{30, 31, 40})
+		assert.DeepEqual(t, result.Deploy, []uint32{3, 4, 6, 7})
+	})
+	t.Run("remove extra vms", func(t *testing.T) {
+		result := planReconcile(1, infos, nodes, 0.2)
+
+		assert.Equal(t, result.Desired, 1)
+		assert.DeepEqual(t, result.Kept, []uint32{1})
+		assert.DeepEqual(t, result.Remove, []uint64{20, 21, 30, 31, 40})
+		assert.Equal(t, len(result.Deploy), 0)
+	})
+}
@@ -0,0 +1,68 @@
+package spawner
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/threefoldtech/zos/pkg/gridtypes"
+)
+
+// deploymentDescription is stamped as JSON into the description of the benchmark workloads
+// so deployments can be traced back to the run that created them.
+type deploymentDescription struct {
+	RunID     string `json:"run_id"`
+	ExpiresAt int64  `json:"expires_at,omitempty"`
+}
+
+// newDeploymentDescription returns the description of the deployments of a run, deployments expire after ttl if set
+func newDeploymentDescription(runID string, ttl time.Duration) deploymentDescription {
+	description := deploymentDescription{RunID: runID}
+	if ttl > 0 {
+		description.ExpiresAt = time.Now().Add(ttl).Unix()
+	}
+
+	return description
+}
+
+// expiry returns the expiry time of the deployment, zero if it never expires
+func (d deploymentDescription) expiry() time.Time {
+	if d.ExpiresAt == 0 {
+		return time.Time{}
+	}
+	return time.Unix(d.ExpiresAt, 0)
+}
+
+// NewRunID generates a new unique run ID prefixed by the current UTC time
+func NewRunID() string {
+	suffix := make([]byte, 3)
+	_, _ = rand.Read(suffix)
+
+	return time.Now().UTC().Format("20060102-150405") + "-" + hex.EncodeToString(suffix)
+}
+
+// encode returns the JSON representation of the description
+func (d deploymentDescription) encode() string {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// parseDescription parses the description of the workloads of a deployment,
+// deployments created before descriptions were stamped return an empty description
+func parseDescription(dl gridtypes.Deployment) deploymentDescription {
+	var description deploymentDescription
+	for _, wl := range dl.Workloads {
+		if wl.Description == "" {
+			continue
+		}
+		if err := json.Unmarshal([]byte(wl.Description), &description); err == nil {
+			return description
+		}
+	}
+
+	return description
+}
@@ -2,11 +2,14 @@ package spawner
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
-	"os"
-	"os/signal"
-	"syscall"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/hashicorp/go-multierror"
@@ -16,6 +19,7 @@ import (
 	"github.com/threefoldtech/tfgrid-sdk-go/grid-client/workloads"
 	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/types"
 	"github.com/threefoldtech/zos/pkg/gridtypes"
+	"golang.org/x/sync/errgroup"
 )
 
 // Represents the configuration for the deployment
@@ -26,6 +30,9 @@ const (
 	rootSize   = 40
 )
 
+// defaultProfile holds the resources of the VMs when no profile is selected
+var defaultProfile = VMProfile{CPU: cpuCount, Memory: memorySize, RootSize: rootSize}
+
 // Represents the deployment strategy
 const (
 	defaultMaxRetries      = 5
@@ -35,50 +42,95 @@ const (
 	stopStrategy           = "stop"
 )
 
+// noNodesErr starts the error returned by grid-client when no node matches the filter
+const noNodesErr = "could not find enough nodes with options"
+
+// nodeErrRegex extracts the node ID from the errors returned by grid-client batch deployers
+var nodeErrRegex = regexp.MustCompile(`node (\d+)`)
+
+// SpawnOptions controls how the VMs are spawned
+type SpawnOptions struct {
+	// RunID identifies the run in the deployments description, a new one is generated if empty
+	RunID string
+	// Nodes only deploys on these nodes, ignoring the deployment strategy, if they are eligible
+	Nodes []uint32
+	// Exclude never deploys on these nodes, such as quarantined ones
+	Exclude []uint32
+}
+
 // Spawn given a list of farm IDs, it spawns VMs on all nodes in these farms
-func Spawn(ctx context.Context, cfg Config, tfPluginClient deployer.TFPluginClient) error {
-	ctx, cancel := context.WithCancel(ctx)
-	defer cancel()
+func Spawn(ctx context.Context, cfg Config, tfPluginClient deployer.TFPluginClient, opts SpawnOptions) (SpawnResult, error) {
 	deploymentStart := time.Now()
 
-	// close ctx on SIGTERM
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
-	go func() {
-		<-sigChan
-		cancel()
-	}()
+	result := SpawnResult{RunID: opts.RunID}
+	if result.RunID == "" {
+		result.RunID = NewRunID()
+	}
+	log.Info().Str("Run", result.RunID).Msg("starting run")
+
+	profile, err := cfg.SelectedProfile()
+	if err != nil {
+		return result, err
+	}
 
 	for _, farm := range cfg.Farms {
 		log.Info().Uint64("Farm", farm).Msg("running deployment")
+		farmResult := FarmResult{Farm: farm}
 
-		nodes, err := getNodes(ctx, tfPluginClient, farm)
-		// TODO: should check error type
+		nodes, err := getNodes(ctx, tfPluginClient, farm, profile)
 		if err != nil {
-			log.Warn().Msgf("failed to get nodes for farm: %d", farm)
+			farmResult.Error = err.Error()
+			farmResult.ErrorClass = ClassOf(classifyError(0, err))
+			result.Farms = append(result.Farms, farmResult)
+			if ctx.Err() != nil {
+				result.Duration = time.Since(deploymentStart)
+				return result, ctx.Err()
+			}
+
+			log.Warn().Err(err).Str("Class", string(farmResult.ErrorClass)).Msgf("failed to get nodes for farm: %d", farm)
 			continue
 		}
-		vmCount := calculateVMCount(nodes, cfg.DeploymentStrategy)
+		for _, node := range nodes {
+			farmResult.Eligible = append(farmResult.Eligible, uint32(node.NodeID))
+		}
+		// nodes is filtered in place below
+		farmResult.Inventory = slices.Clone(nodes)
+		// the excluded nodes are still recorded as eligible, so they are not reported as dropped by the diff of the runs
+		nodes = excludeNodes(nodes, opts.Exclude)
+		vmCount := calculateVMCount(len(nodes), cfg.DeploymentStrategy)
+		if len(opts.Nodes) != 0 {
+			nodes = slices.DeleteFunc(nodes, func(node types.Node) bool {
+				return !slices.Contains(opts.Nodes, uint32(node.NodeID))
+			})
+			vmCount = len(nodes)
+		}
 		if vmCount == 0 {
 			log.Warn().Msg("there is nothing to deploy")
+			result.Farms = append(result.Farms, farmResult)
 			continue
 		}
-		err = spawn(ctx, tfPluginClient, cfg, nodes, vmCount)
+		farmResult.Nodes, err = spawn(ctx, tfPluginClient, cfg, profile, result.RunID, nodes, vmCount)
 		if err != nil {
-			return err
+			farmResult.Error = err.Error()
+			farmResult.ErrorClass = ClassOf(err)
+		}
+		result.Farms = append(result.Farms, farmResult)
+		if err != nil {
+			result.Duration = time.Since(deploymentStart)
+			return result, err
 		}
 	}
-	endTime := time.Since(deploymentStart)
-	log.Info().Msgf("deployment took %s", endTime)
+	result.Duration = time.Since(deploymentStart)
+	log.Info().Msgf("deployment took %s", result.Duration)
 
-	return nil
+	return result, nil
 }
 
-// getNodes returns all the nodes on a specified farm
-func getNodes(ctx context.Context, tfPluginClient deployer.TFPluginClient, farm uint64) ([]types.Node, error) {
+// getNodes returns all the nodes on a specified farm with enough resources for the profile
+func getNodes(ctx context.Context, tfPluginClient deployer.TFPluginClient, farm uint64, profile VMProfile) ([]types.Node, error) {
 	trueVal := true
-	freeMRU := uint64(memorySize * gb)
-	freeSRU := uint64(rootSize * gb)
+	freeMRU := uint64(profile.Memory * gb)
+	freeSRU := uint64(profile.RootSize * gb)
 
 	filter := types.NodeFilter{
 		Status:  []string{"up"},
@@ -88,6 +140,13 @@ func getNodes(ctx context.Context, tfPluginClient deployer.TFPluginClient, farm
 		FarmIDs: []uint64{farm},
 	}
 	nodes, err := deployer.FilterNodes(ctx, tfPluginClient, filter, nil, nil, []uint64{freeSRU})
+	if err != nil && strings.HasPrefix(err.Error(), noNodesErr) {
+		// grid-client also reports failing to query the proxy as no nodes found
+		if pingErr := tfPluginClient.GridProxyClient.Ping(); pingErr != nil {
+			return nil, GridUnreachableError{Err: pingErr}
+		}
+		return nil, nil
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -95,20 +154,28 @@ func getNodes(ctx context.Context, tfPluginClient deployer.TFPluginClient, farm
 	return nodes, nil
 }
 
-// calculateVMCount calculates the number of VMs to deploy based on the deployment strategy
-func calculateVMCount(nodes []types.Node, strategy float64) int {
-	totalNodes := len(nodes)
+// excludeNodes removes the excluded nodes in place
+func excludeNodes(nodes []types.Node, exclude []uint32) []types.Node {
+	return slices.DeleteFunc(nodes, func(node types.Node) bool {
+		return slices.Contains(exclude, uint32(node.NodeID))
+	})
+}
 
+// calculateVMCount calculates the number of VMs to deploy based on the deployment strategy
+func calculateVMCount(totalNodes int, strategy float64) int {
 	return int(float64(totalNodes) * strategy)
 }
 
 // spawn creates and deploys VMs on the specified nodes according to the provided configuration
-func spawn(ctx context.Context, tfPluginClient deployer.TFPluginClient, cfg Config, nodes []types.Node, vmCount int) error {
-	networks, vms, err := getDeployment(cfg, nodes, vmCount)
+func spawn(ctx context.Context, tfPluginClient deployer.TFPluginClient, cfg Config, profile VMProfile, runID string, nodes []types.Node, vmCount int) ([]NodeResult, error) {
+	networks, vms, err := getDeployment(cfg, profile, runID, nodes, vmCount)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	results := newNodeResults(vms)
+	deploymentStart := time.Now()
+
 	var resultErr *multierror.Error
 	retryCount := 1
 
@@ -117,13 +184,14 @@ func spawn(ctx context.Context, tfPluginClient deployer.TFPluginClient, cfg Conf
 			log.Info().Int("Retry", retryCount).Msg("Retrying deployment")
 		}
 
-		err := deployDeployments(ctx, tfPluginClient, vms, networks)
+		stages, err := deployDeployments(ctx, tfPluginClient, vms, networks)
+		results.update(vms, networks, err, time.Since(deploymentStart), stages)
 		if err != nil {
 			log.Debug().Err(err).Msg("deployment failed")
 			resultErr = multierror.Append(resultErr, err)
 		}
 
-		if resultErr == nil {
+		if err == nil {
 			return nil
 		}
 
@@ -132,7 +200,13 @@ func spawn(ctx context.Context, tfPluginClient deployer.TFPluginClient, cfg Conf
 			return resultErr
 
 		case destroyAllStrategy:
-			return Destroy(ctx, cfg, tfPluginClient)
+			results.abort(errors.New("deployment destroyed by destroy-all failure strategy"))
+			contracts := results.contracts()
+			if len(contracts) == 0 {
+				return nil
+			}
+			_, err := Destroy(ctx, cfg, tfPluginClient, DestroyOptions{Contracts: contracts})
+			return err
 
 		case retryStrategy:
 			vms, networks = identifyFailingResources(vms, networks)
@@ -152,41 +226,61 @@ func spawn(ctx context.Context, tfPluginClient deployer.TFPluginClient, cfg Conf
 		return nil
 	})
 
+	results.loadIPs(ctx, tfPluginClient)
+
 	if err != nil {
 		log.Error().Err(resultErr.ErrorOrNil()).Msg("Deployment failed after retries")
-		return resultErr
+		if nodesErr := results.err(); nodesErr != nil {
+			return results.list(), nodesErr
+		}
+		return results.list(), resultErr
 	}
 
-	return nil
+	return results.list(), nil
+}
+
+// stageDurations holds how long each stage of a deployment attempt took
+type stageDurations struct {
+	network time.Duration
+	vm      time.Duration
 }
 
 // deployDeployments deploys the specified VMs and networks
-func deployDeployments(ctx context.Context, tfPluginClient deployer.TFPluginClient, vms []*workloads.Deployment, networks []*workloads.ZNet) error {
+func deployDeployments(ctx context.Context, tfPluginClient deployer.TFPluginClient, vms []*workloads.Deployment, networks []*workloads.ZNet) (stageDurations, error) {
+	var stages stageDurations
+
+	start := time.Now()
 	err := tfPluginClient.NetworkDeployer.BatchDeploy(ctx, networks)
+	stages.network = time.Since(start)
 	if err != nil {
-		return err
+		return stages, err
 	}
 
+	start = time.Now()
 	err = tfPluginClient.DeploymentDeployer.BatchDeploy(ctx, vms)
+	stages.vm = time.Since(start)
 	if err != nil {
-		return err
+		return stages, err
 	}
 
-	return nil
+	return stages, nil
 }
 
 // getDeployment creates the deployment configuration for the specified nodes
-func getDeployment(cfg Config, nodes []types.Node, vmCount int) ([]*workloads.ZNet, []*workloads.Deployment, error) {
+func getDeployment(cfg Config, profile VMProfile, runID string, nodes []types.Node, vmCount int) ([]*workloads.ZNet, []*workloads.Deployment, error) {
 	var networks []*workloads.ZNet
 	var vms []*workloads.Deployment
 
+	description := newDeploymentDescription(runID, cfg.TTL).encode()
+
 	for i := 0; i < vmCount; i++ {
 		node := nodes[i]
 		name := fmt.Sprintf("vm/%d", node.FarmID)
 
 		network := workloads.ZNet{
-			Name:  fmt.Sprintf("network_%d", node.NodeID),
-			Nodes: []uint32{uint32(node.NodeID)},
+			Name:        fmt.Sprintf("network_%d", node.NodeID),
+			Description: description,
+			Nodes:       []uint32{uint32(node.NodeID)},
 			IPRange: gridtypes.NewIPNet(net.IPNet{
 				IP:   net.IPv4(10, 20, 0, 0),
 				Mask: net.CIDRMask(16, 32),
@@ -196,12 +290,13 @@ func getDeployment(cfg Config, nodes []types.Node, vmCount int) ([]*workloads.ZN
 		vm := workloads.VM{
 			Name:        fmt.Sprintf("vm_%d", node.NodeID),
 			Flist:       "https://hub.grid.tf/amryassir.3bot/benchmark.flist",
-			CPU:         cpuCount,
+			CPU:         profile.CPU,
 			Planetary:   true,
-			Memory:      memorySize * 1024,
-			RootfsSize:  rootSize * 1024,
+			Memory:      profile.Memory * 1024,
+			RootfsSize:  profile.RootSize * 1024,
 			Entrypoint:  "/sbin/zinit init",
 			NetworkName: network.Name,
+			Description: description,
 			EnvVars: map[string]string{
 				"INFLUX_URL":    cfg.Influx.URL,
 				"INFLUX_ORG":    cfg.Influx.Org,
@@ -246,3 +341,168 @@ func identifyFailingResources(vms []*workloads.Deployment, networks []*workloads
 
 	return failingVMs, failingNetworks
 }
+
+// nodeResults tracks the deployment outcome of every node of a farm across retries
+type nodeResults struct {
+	order   []uint32
+	results map[uint32]*NodeResult
+	names   map[uint32]string
+	errs    map[uint32]error
+}
+
+// newNodeResults creates a result entry for every node that gets a VM
+func newNodeResults(vms []*workloads.Deployment) nodeResults {
+	r := nodeResults{
+		results: make(map[uint32]*NodeResult, len(vms)),
+		names:   make(map[uint32]string, len(vms)),
+		errs:    make(map[uint32]error, len(vms)),
+	}
+
+	for _, vm := range vms {
+		r.order = append(r.order, vm.NodeID)
+		r.results[vm.NodeID] = &NodeResult{Node: vm.NodeID}
+		r.names[vm.NodeID] = vm.Name
+	}
+
+	return r
+}
+
+// update records the outcome of a deployment attempt for the given VMs and networks
+func (r nodeResults) update(vms []*workloads.Deployment, networks []*workloads.ZNet, err error, elapsed time.Duration, stages stageDurations) {
+	errs := nodeErrors(err)
+
+	for idx, vm := range vms {
+		result := r.results[vm.NodeID]
+		result.Attempts++
+		result.Duration = elapsed
+		result.NetworkDuration += stages.network
+		result.VMDuration += stages.vm
+		result.NetworkContractID = networks[idx].NodeDeploymentID[vm.NodeID]
+		result.VMContractID = vm.ContractID
+
+		if vm.ContractID != 0 && result.NetworkContractID != 0 {
+			result.Error = ""
+			result.ErrorClass = ""
+			delete(r.errs, vm.NodeID)
+			continue
+		}
+
+		nodeErr, ok := errs[vm.NodeID]
+		if !ok {
+			nodeErr = err
+		}
+		if nodeErr == nil {
+			nodeErr = errors.New("deployment was not created")
+		}
+		r.setError(vm.NodeID, classifyError(vm.NodeID, nodeErr))
+	}
+}
+
+// setError records the deployment error of a node
+func (r nodeResults) setError(nodeID uint32, err error) {
+	r.errs[nodeID] = err
+	r.results[nodeID].Error = err.Error()
+	r.results[nodeID].ErrorClass = ClassOf(err)
+}
+
+// err returns the typed errors of all failed nodes
+func (r nodeResults) err() error {
+	var resultErr *multierror.Error
+	for _, nodeID := range r.order {
+		if err, ok := r.errs[nodeID]; ok {
+			resultErr = multierror.Append(resultErr, err)
+		}
+	}
+
+	return resultErr.ErrorOrNil()
+}
+
+// abort marks all node results as aborted with the given error
+func (r nodeResults) abort(err error) {
+	for nodeID := range r.results {
+		r.setError(nodeID, AbortedError{nodeError{Node: nodeID, Err: err}})
+	}
+}
+
+// contracts returns the contracts created on all nodes so far
+func (r nodeResults) contracts() []uint64 {
+	var contracts []uint64
+	for _, nodeID := range r.order {
+		contracts = append(contracts, r.results[nodeID].Contracts()...)
+	}
+
+	return contracts
+}
+
+// loadIPs loads the planetary and mycelium IPs of the successfully deployed VMs
+func (r nodeResults) loadIPs(ctx context.Context, tfPluginClient deployer.TFPluginClient) {
+	var (
+		group errgroup.Group
+		mu    sync.Mutex
+	)
+	group.SetLimit(10)
+
+	for nodeID, result := range r.results {
+		if !result.Succeeded() {
+			continue
+		}
+
+		nodeID, name := nodeID, r.names[nodeID]
+		group.Go(func() error {
+			vm, err := tfPluginClient.State.LoadVMFromGrid(ctx, nodeID, name, name)
+			if err != nil {
+				log.Warn().Err(err).Uint32("Node", nodeID).Msg("failed to load VM IPs")
+				return nil
+			}
+
+			mu.Lock()
+			r.results[nodeID].PlanetaryIP = vm.PlanetaryIP
+			r.results[nodeID].MyceliumIP = vm.MyceliumIP
+			mu.Unlock()
+			return nil
+		})
+	}
+
+	_ = group.Wait()
+}
+
+// list returns the node results in deployment order
+func (r nodeResults) list() []NodeResult {
+	list := make([]NodeResult, 0, len(r.order))
+	for _, nodeID := range r.order {
+		list = append(list, *r.results[nodeID])
+	}
+
+	return list
+}
+
+// nodeErrors maps the errors of a batch deployment to the nodes they belong to
+func nodeErrors(err error) map[uint32]error {
+	errs := make(map[uint32]error)
+
+	var merr *multierror.Error
+	if !errors.As(err, &merr) {
+		return errs
+	}
+
+	for _, e := range merr.Errors {
+		if nested := nodeErrors(e); len(nested) != 0 {
+			for nodeID, nodeErr := range nested {
+				errs[nodeID] = nodeErr
+			}
+			continue
+		}
+
+		match := nodeErrRegex.FindStringSubmatch(e.Error())
+		if len(match) != 2 {
+			continue
+		}
+		nodeID, parseErr := strconv.ParseUint(match[1], 10, 32)
+		if parseErr != nil {
+			continue
+		}
+		errs[uint32(nodeID)] = e
+	}
+
+	return errs
+}
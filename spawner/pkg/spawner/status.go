@@ -0,0 +1,140 @@
+package spawner
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-client/deployer"
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-client/graphql"
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-client/workloads"
+	"github.com/threefoldtech/zos/pkg/gridtypes"
+	"golang.org/x/sync/errgroup"
+)
+
+// Status fetches the benchmark deployments of the farms in the config file and reports the state of their workloads.
+func Status(ctx context.Context, cfg Config, tfPluginClient deployer.TFPluginClient) ([]DeploymentStatus, error) {
+	var (
+		statuses  []DeploymentStatus
+		resultErr *multierror.Error
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+	)
+
+	for _, farm := range cfg.Farms {
+		wg.Add(1)
+		go func(farm uint64) {
+			defer wg.Done()
+			farmStatuses, err := farmStatus(ctx, farm, tfPluginClient)
+			mu.Lock()
+			statuses = append(statuses, farmStatuses...)
+			if err != nil {
+				resultErr = multierror.Append(resultErr, err)
+			}
+			mu.Unlock()
+		}(farm)
+	}
+
+	wg.Wait()
+
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].Farm != statuses[j].Farm {
+			return statuses[i].Farm < statuses[j].Farm
+		}
+		if statuses[i].Node != statuses[j].Node {
+			return statuses[i].Node < statuses[j].Node
+		}
+		return statuses[i].Contract < statuses[j].Contract
+	})
+
+	return statuses, resultErr.ErrorOrNil()
+}
+
+// farmStatus fetches the deployments of all contracts of a farm
+func farmStatus(ctx context.Context, farm uint64, tfPluginClient deployer.TFPluginClient) ([]DeploymentStatus, error) {
+	name := fmt.Sprintf("vm/%d", farm)
+	contracts, err := tfPluginClient.ContractsGetter.ListContractsOfProjectName(name, true)
+	if err != nil {
+		return nil, fmt.Errorf("error listing contracts for farm %d: %w", farm, err)
+	}
+
+	var (
+		farmGroup errgroup.Group
+		statuses  []DeploymentStatus
+		mu        sync.Mutex
+	)
+	farmGroup.SetLimit(10)
+
+	for _, contract := range contracts.NodeContracts {
+		contract := contract
+		farmGroup.Go(func() error {
+			status := deploymentStatus(ctx, contract, farm, tfPluginClient)
+			mu.Lock()
+			statuses = append(statuses, status)
+			mu.Unlock()
+			return nil
+		})
+	}
+
+	_ = farmGroup.Wait()
+
+	return statuses, nil
+}
+
+// deploymentStatus fetches the deployment of a contract from its node and collects the state of its workloads
+func deploymentStatus(ctx context.Context, contract graphql.Contract, farm uint64, tfPluginClient deployer.TFPluginClient) DeploymentStatus {
+	status := DeploymentStatus{
+		Farm: farm,
+		Node: contract.NodeID,
+	}
+
+	deploymentData, err := workloads.ParseDeploymentData(contract.DeploymentData)
+	if err == nil {
+		status.Name = deploymentData.Name
+		status.Type = deploymentData.Type
+	}
+
+	contractID, err := strconv.ParseUint(contract.ContractID, 10, 64)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	status.Contract = contractID
+
+	dl, err := getContractDeployment(ctx, contract.NodeID, contractID, tfPluginClient)
+	if err != nil {
+		err = classifyError(contract.NodeID, err)
+		status.Error = err.Error()
+		status.ErrorClass = ClassOf(err)
+		return status
+	}
+
+	for _, wl := range dl.Workloads {
+		status.Workloads = append(status.Workloads, WorkloadStatus{
+			Name:  wl.Name.String(),
+			Type:  wl.Type.String(),
+			State: string(wl.Result.State),
+			Error: wl.Result.Error,
+		})
+	}
+
+	return status
+}
+
+// getContractDeployment fetches the deployment of a contract from its node
+func getContractDeployment(ctx context.Context, nodeID uint32, contractID uint64, tfPluginClient deployer.TFPluginClient) (gridtypes.Deployment, error) {
+	nodeClient, err := tfPluginClient.State.NcPool.GetNodeClient(tfPluginClient.State.Substrate, nodeID)
+	if err != nil {
+		return gridtypes.Deployment{}, fmt.Errorf("failed to get node %d client: %w", nodeID, err)
+	}
+
+	dl, err := nodeClient.DeploymentGet(ctx, contractID)
+	if err != nil {
+		return gridtypes.Deployment{}, fmt.Errorf("failed to get deployment %d from node %d: %w", contractID, nodeID, err)
+	}
+
+	return dl, nil
+}
@@ -1,14 +1,66 @@
 package spawner
 
+import (
+	"fmt"
+	"time"
+
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/types"
+	"github.com/threefoldtech/zos/pkg/gridtypes"
+)
+
 // Config holds the configuration settings for the spawner tool.
 type Config struct {
-	Farms              []uint64     `yaml:"farms"`
-	DeploymentStrategy float64      `yaml:"deployment_strategy"`
-	GridEndpoints      Endpoints    `yaml:"grid_endpoints"`
-	Mnemonic           string       `yaml:"mnemonic"`
-	FailureStrategy    string       `yaml:"failure_strategy"`
-	SSHKey             string       `yaml:"ssh_key"`
-	Influx             InfluxConfig `yaml:"influx"`
+	Farms              []uint64         `yaml:"farms"`
+	DeploymentStrategy float64          `yaml:"deployment_strategy"`
+	GridEndpoints      Endpoints        `yaml:"grid_endpoints"`
+	Mnemonic           string           `yaml:"mnemonic"`
+	FailureStrategy    string           `yaml:"failure_strategy"`
+	SSHKey             string           `yaml:"ssh_key"`
+	Influx             InfluxConfig     `yaml:"influx"`
+	TTL                time.Duration    `yaml:"ttl,omitempty"`
+	Campaigns          []Campaign       `yaml:"campaigns,omitempty"`
+	Quarantine         QuarantineConfig `yaml:"quarantine,omitempty"`
+	// Profile selects the resources of the spawned VMs out of Profiles, the default resources are used if empty
+	Profile  string               `yaml:"profile,omitempty"`
+	Profiles map[string]VMProfile `yaml:"profiles,omitempty"`
+}
+
+// VMProfile holds the resources of the spawned VMs, Memory and RootSize are in GB.
+type VMProfile struct {
+	CPU      int `yaml:"cpu" json:"cpu"`
+	Memory   int `yaml:"memory" json:"memory"`
+	RootSize int `yaml:"root_size" json:"root_size"`
+}
+
+// SelectedProfile returns the resources of the VMs of the selected profile.
+func (c Config) SelectedProfile() (VMProfile, error) {
+	if c.Profile == "" {
+		return defaultProfile, nil
+	}
+
+	profile, ok := c.Profiles[c.Profile]
+	if !ok {
+		return VMProfile{}, fmt.Errorf("unknown profile: %s", c.Profile)
+	}
+	return profile, nil
+}
+
+// QuarantineConfig sets when nodes failing their deployments are quarantined, a node failing
+// Threshold deployments in a row is excluded from the runs for Cooldown. A zero threshold
+// disables the automatic quarantine.
+type QuarantineConfig struct {
+	Threshold int           `yaml:"threshold"`
+	Cooldown  time.Duration `yaml:"cooldown"`
+}
+
+// Campaign is a health check run by the daemon on a cron schedule, each cycle spawns the VMs,
+// waits for the benchmark duration then destroys them.
+type Campaign struct {
+	Name     string        `yaml:"name"`
+	Schedule string        `yaml:"schedule"`
+	Duration time.Duration `yaml:"duration"`
+	// Farms overrides the configured farms for this campaign
+	Farms []uint64 `yaml:"farms,omitempty"`
 }
 
 // Endpoints holds the URLs for grid
@@ -27,13 +79,208 @@ type InfluxConfig struct {
 	Bucket string `yaml:"bucket"`
 }
 
-// vmInfo stores information about a specific VM.
-type vmInfo struct {
-	Farm        uint64 `json:"farm"`
-	Node        uint32 `json:"node"`
-	Name        string `json:"name"`
-	Contract    uint64 `json:"contract"`
-	ProjectName string `json:"project_name"`
+// SpawnResult holds the outcome of a spawn run over all configured farms.
+type SpawnResult struct {
+	RunID    string        `json:"run_id"`
+	Farms    []FarmResult  `json:"farms"`
+	Duration time.Duration `json:"duration"`
+}
+
+// FarmResult holds the outcome of the deployments on a single farm,
+// Eligible holds the nodes of the farm which met the deployment requirements when the run started
+// and Inventory their grid proxy records at that time, so the results can be interpreted after the nodes change.
+// Inventory is left out of the output and the reports, the run history keeps it.
+type FarmResult struct {
+	Farm       uint64       `json:"farm"`
+	Eligible   []uint32     `json:"eligible,omitempty"`
+	Inventory  []types.Node `json:"-"`
+	Nodes      []NodeResult `json:"nodes"`
+	Error      string       `json:"error,omitempty"`
+	ErrorClass ErrorClass   `json:"error_class,omitempty"`
+}
+
+// NodeResult holds the outcome of the deployment on a single node,
+// NetworkDuration and VMDuration are the time spent in each deployment stage over all attempts.
+type NodeResult struct {
+	Node              uint32        `json:"node"`
+	NetworkContractID uint64        `json:"network_contract_id"`
+	VMContractID      uint64        `json:"vm_contract_id"`
+	PlanetaryIP       string        `json:"planetary_ip"`
+	MyceliumIP        string        `json:"mycelium_ip"`
+	Attempts          int           `json:"attempts"`
+	Duration          time.Duration `json:"duration"`
+	NetworkDuration   time.Duration `json:"network_duration"`
+	VMDuration        time.Duration `json:"vm_duration"`
+	Error             string        `json:"error,omitempty"`
+	ErrorClass        ErrorClass    `json:"error_class,omitempty"`
+}
+
+// LookupFailed reports whether the eligible nodes of the farm could not be looked up,
+// a farm without any eligible node has no error.
+func (r FarmResult) LookupFailed() bool {
+	return r.Error != "" && len(r.Eligible) == 0
+}
+
+// Succeeded reports whether both the network and the VM were deployed on the node.
+func (r NodeResult) Succeeded() bool {
+	return r.Error == "" && r.VMContractID != 0
+}
+
+// Contracts returns the network and VM contracts created on the node.
+func (r NodeResult) Contracts() []uint64 {
+	var contracts []uint64
+	for _, contract := range []uint64{r.NetworkContractID, r.VMContractID} {
+		if contract != 0 {
+			contracts = append(contracts, contract)
+		}
+	}
+
+	return contracts
+}
+
+// Counts returns the number of succeeded and failed node deployments of the run.
+func (r SpawnResult) Counts() (succeeded, failed int) {
+	for _, farm := range r.Farms {
+		for _, node := range farm.Nodes {
+			if node.Succeeded() {
+				succeeded++
+			} else {
+				failed++
+			}
+		}
+	}
+
+	return succeeded, failed
+}
+
+// Contracts returns the network and VM contracts created by the run on all nodes.
+func (r SpawnResult) Contracts() []uint64 {
+	var contracts []uint64
+	for _, farm := range r.Farms {
+		for _, node := range farm.Nodes {
+			contracts = append(contracts, node.Contracts()...)
+		}
+	}
+
+	return contracts
+}
+
+// DestroyPlan holds the contracts selected for cancellation on every farm.
+type DestroyPlan struct {
+	Farms []FarmDestroyPlan `json:"farms"`
+}
+
+// FarmDestroyPlan holds the contracts selected for cancellation on a single farm.
+type FarmDestroyPlan struct {
+	Farm      uint64   `json:"farm"`
+	Contracts []uint64 `json:"contracts"`
+	// Unverified holds the contracts on unreachable nodes which could not be checked against the filters
+	Unverified []uint64 `json:"unverified,omitempty"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// Total returns the number of contracts selected for cancellation on all farms.
+func (p DestroyPlan) Total() int {
+	total := 0
+	for _, farm := range p.Farms {
+		total += len(farm.Contracts)
+	}
+	return total
+}
+
+// DestroyResult holds the outcome of destroying the deployments of all configured farms.
+type DestroyResult struct {
+	Farms []FarmDestroyResult `json:"farms"`
+}
+
+// FarmDestroyResult holds the outcome of destroying the deployments of a single farm.
+type FarmDestroyResult struct {
+	Farm      uint64   `json:"farm"`
+	Cancelled []uint64 `json:"cancelled"`
+	Remaining []uint64 `json:"remaining"`
+	// Unverified holds the contracts on unreachable nodes which could not be checked against the filters
+	Unverified []uint64 `json:"unverified,omitempty"`
+	Attempts   int      `json:"attempts"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// ReconcileResult holds the changes of a reconciliation pass over all configured farms.
+type ReconcileResult struct {
+	RunID string                `json:"run_id"`
+	Farms []FarmReconcileResult `json:"farms"`
+}
+
+// FarmReconcileResult holds the changes needed to bring a single farm to its desired number of VMs.
+type FarmReconcileResult struct {
+	Farm    uint64 `json:"farm"`
+	Desired int    `json:"desired"`
+	// Kept are the nodes with a healthy deployment which is kept
+	Kept []uint32 `json:"kept"`
+	// Remove are the contracts of errored, unreachable or extra deployments
+	Remove []uint64 `json:"remove"`
+	// Deploy are the nodes getting a new deployment
+	Deploy   []uint32     `json:"deploy"`
+	Deployed []NodeResult `json:"deployed,omitempty"`
+	Error    string       `json:"error,omitempty"`
+}
+
+// Orphan holds a benchmark contract which is no longer part of a healthy benchmark deployment.
+type Orphan struct {
+	Farm     uint64       `json:"farm"`
+	Node     uint32       `json:"node"`
+	Contract uint64       `json:"contract"`
+	Name     string       `json:"name"`
+	Type     string       `json:"type"`
+	RunID    string       `json:"run_id"`
+	Reason   OrphanReason `json:"reason"`
+}
+
+// DeploymentStatus holds the state of the workloads of a benchmark deployment.
+type DeploymentStatus struct {
+	Farm       uint64           `json:"farm"`
+	Node       uint32           `json:"node"`
+	Contract   uint64           `json:"contract"`
+	Name       string           `json:"name"`
+	Type       string           `json:"type"`
+	Workloads  []WorkloadStatus `json:"workloads"`
+	Error      string           `json:"error,omitempty"`
+	ErrorClass ErrorClass       `json:"error_class,omitempty"`
+}
+
+// Healthy reports whether the deployment could be fetched and all its workloads are ok.
+func (s DeploymentStatus) Healthy() bool {
+	if s.Error != "" {
+		return false
+	}
+	for _, wl := range s.Workloads {
+		if wl.State != string(gridtypes.StateOk) {
+			return false
+		}
+	}
+	return true
+}
+
+// WorkloadStatus holds the state reported by zos for a single workload.
+type WorkloadStatus struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	State string `json:"state"`
+	Error string `json:"error,omitempty"`
+}
+
+// VMInfo stores information about a specific benchmark deployment.
+type VMInfo struct {
+	Farm        uint64    `json:"farm"`
+	Node        uint32    `json:"node"`
+	Name        string    `json:"name"`
+	Contract    uint64    `json:"contract"`
+	ProjectName string    `json:"project_name"`
+	Type        string    `json:"type"`
+	RunID       string    `json:"run_id"`
+	Created     time.Time `json:"created"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	Failed      bool      `json:"failed"`
+	Error       string    `json:"error,omitempty"`
 }
 
 // deploymentMetadata holds metadata for a deployment.